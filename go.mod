@@ -10,6 +10,7 @@ require (
 	github.com/charmbracelet/x/ansi v0.6.0
 	github.com/charmbracelet/x/exp/teatest v0.0.0-20241220083205-e9f42afc4e49
 	github.com/davecgh/go-spew v1.1.1
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/go-logfmt/logfmt v0.6.0
 	github.com/google/uuid v1.6.0
 	github.com/hashicorp/hcl/v2 v2.23.0
@@ -17,7 +18,9 @@ require (
 	github.com/otiai10/copy v1.14.0
 	github.com/peterbourgon/ff/v4 v4.0.0-alpha.4
 	github.com/stretchr/testify v1.9.0
+	github.com/zclconf/go-cty v1.15.1
 	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842
+	golang.org/x/sys v0.28.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -58,13 +61,11 @@ require (
 	github.com/muesli/termenv v0.15.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/zclconf/go-cty v1.15.1 // indirect
 	golang.org/x/crypto v0.30.0 // indirect
 	golang.org/x/mod v0.22.0 // indirect
 	golang.org/x/net v0.32.0 // indirect
 	golang.org/x/oauth2 v0.20.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 	golang.org/x/tools v0.28.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
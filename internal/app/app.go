@@ -10,8 +10,10 @@ import (
 	"github.com/leg100/pug/internal/logging"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/state"
 	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workflow"
 	"github.com/leg100/pug/internal/workspace"
 )
 
@@ -24,6 +26,7 @@ type App struct {
 	Plans      *plan.Service
 	States     *state.Service
 	Tasks      *task.Service
+	Workflows  *workflow.Service
 }
 
 // New starts the application, constructing services, starting daemons and
@@ -38,6 +41,7 @@ func New(cfg Config) (*App, error) {
 		"log_level", cfg.Logging.Level,
 		"max_tasks", cfg.MaxTasks,
 		"plugin_cache", cfg.PluginCache,
+		"watch", !cfg.DisableWatch,
 		"program", cfg.Program,
 		"work_dir", cfg.Workdir,
 		"data_dir", cfg.DataDir,
@@ -45,12 +49,20 @@ func New(cfg Config) (*App, error) {
 
 	// Instantiate services
 	tasks := task.NewService(task.ServiceOptions{
-		Program:    cfg.Program,
-		Logger:     logger,
-		Workdir:    cfg.Workdir,
-		UserEnvs:   cfg.Envs,
-		UserArgs:   cfg.Args,
-		Terragrunt: cfg.Terragrunt,
+		Program:      cfg.Program,
+		Logger:       logger,
+		Workdir:      cfg.Workdir,
+		UserEnvs:     cfg.Envs,
+		UserArgs:     cfg.Args,
+		Terragrunt:   cfg.Terragrunt,
+		Timeouts:     cfg.Timeouts,
+		Priorities:   taskPriorities,
+		Retry:        cfg.Retry,
+		Hooks:        cfg.Hooks,
+		PTY:          cfg.PTY,
+		DataDir:      cfg.DataDir,
+		MirrorOutput: cfg.MirrorTaskOutput,
+		GroupPolicy:  cfg.GroupPolicy,
 	})
 	modules := module.NewService(module.ServiceOptions{
 		Tasks:       tasks,
@@ -58,13 +70,18 @@ func New(cfg Config) (*App, error) {
 		PluginCache: cfg.PluginCache,
 		Logger:      logger,
 		Terragrunt:  cfg.Terragrunt,
+		Ignore:      cfg.Ignore,
+		DataDir:     cfg.DataDir,
+
+		DiscoverLocal: cfg.DiscoverLocalModules,
 	})
 	workspaces := workspace.NewService(workspace.ServiceOptions{
-		Tasks:   tasks,
-		Modules: modules,
-		Logger:  logger,
-		DataDir: cfg.DataDir,
-		Workdir: cfg.Workdir,
+		Tasks:      tasks,
+		Modules:    modules,
+		Logger:     logger,
+		DataDir:    cfg.DataDir,
+		Workdir:    cfg.Workdir,
+		Protection: cfg.Protection,
 	})
 	states := state.NewService(state.ServiceOptions{
 		Modules:    modules,
@@ -81,13 +98,43 @@ func New(cfg Config) (*App, error) {
 		Workdir:    cfg.Workdir,
 		Logger:     logger,
 		Terragrunt: cfg.Terragrunt,
+		Policies:   cfg.Policies,
+	})
+
+	workflows := workflow.NewService(workflow.ServiceOptions{
+		Workflows: cfg.Workflows,
+		Modules:   modules,
+		Plans:     plans,
+		Tasks:     tasks,
+		Logger:    logger,
 	})
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Start daemons
 	task.StartEnqueuer(tasks)
-	waitTasks := task.StartRunner(ctx, logger, tasks, cfg.MaxTasks)
+	waitTasks := task.StartRunner(ctx, logger, tasks, task.RunnerOptions{
+		MaxTasks: cfg.MaxTasks,
+		Limits:   cfg.ConcurrencyLimits,
+		Backend: func(moduleID resource.ID) string {
+			mod, err := modules.Get(moduleID)
+			if err != nil {
+				return ""
+			}
+			return mod.Backend
+		},
+	})
+
+	// Watch working directory for changes to modules, workspaces and local
+	// state. Failure to watch is not fatal; the user can still reload manually.
+	if !cfg.DisableWatch {
+		if changes, stateChanges, err := modules.Watch(ctx); err != nil {
+			logger.Error("watching working directory", "error", err)
+		} else {
+			go workspaces.LoadWorkspacesUponChange(changes)
+			go states.ReloadUponLocalStateChange(stateChanges)
+		}
+	}
 
 	// cleanup function to be invoked when app is terminated.
 	cleanup := func() {
@@ -108,6 +155,9 @@ func New(cfg Config) (*App, error) {
 		// shut itself down.
 		waitTasks()
 
+		// Remove task output spilled to disk.
+		tasks.RemoveOutput()
+
 		// Remove all run artefacts (plan files etc,...)
 		for _, plan := range plans.List() {
 			_ = os.RemoveAll(plan.ArtefactsPath)
@@ -120,6 +170,7 @@ func New(cfg Config) (*App, error) {
 		Plans:      plans,
 		Tasks:      tasks,
 		States:     states,
+		Workflows:  workflows,
 		Cleanup:    cleanup,
 		Logger:     logger,
 	}, nil
@@ -1,19 +1,30 @@
 package app
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform/command/cliconfig"
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workflow"
+	"github.com/leg100/pug/internal/workspace"
 	"github.com/peterbourgon/ff/v4"
 	"github.com/peterbourgon/ff/v4/ffhelp"
 	"github.com/peterbourgon/ff/v4/ffyaml"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
@@ -22,16 +33,63 @@ type Config struct {
 	PluginCache             bool
 	Debug                   bool
 	DisableReloadAfterApply bool
+	DisableWatch            bool
 	Workdir                 internal.Workdir
 	DataDir                 string
 	Envs                    []string
 	Args                    []string
 	Terragrunt              bool
+	Ignore                  module.IgnoreOptions
+	DiscoverLocalModules    bool
+	Timeouts                task.Timeouts
+	Retry                   task.RetryPolicy
+	ConcurrencyLimits       task.ConcurrencyLimits
+	Hooks                   task.Hooks
+	PTY                     []string
+	MirrorTaskOutput        bool
+	GroupPolicy             task.GroupPolicy
+	Protection              workspace.Protection
+	Workflows               []workflow.Workflow
+	Policies                []plan.Policy
 	Logging                 logging.Options
 
 	Version bool
 }
 
+// taskIdentifiers identify the types of task that pug creates, for which
+// timeouts and hooks can be set. A task without its own identifier is
+// identified by its terraform command.
+var taskIdentifiers = []task.Identifier{
+	module.InitTask,
+	"validate",
+	"fmt",
+	module.FormatCheckTask,
+	"graph-dependencies",
+	plan.PlanTask,
+	plan.ApplyTask,
+	workspace.ReloadTask,
+	"workspace new",
+	"workspace select",
+	"workspace delete",
+	workspace.ForceUnlockTask,
+	state.ReloadTask,
+	state.DeleteTask,
+	"state mv",
+	"taint",
+	"untaint",
+	workspace.CostTask,
+}
+
+// taskPriorities are the default priorities of types of task.
+var taskPriorities = map[task.Identifier]task.Priority{
+	// Quick task that keeps the state shown to the user up to date.
+	state.ReloadTask: task.HighPriority,
+	// Quick task that populates the workspaces other tasks depend upon.
+	workspace.ReloadTask: task.HighPriority,
+	// Cost estimation is not urgent, so let other tasks go first.
+	workspace.CostTask: task.LowPriority,
+}
+
 // set config in order of precedence:
 // 1. flags > 2. env vars > 3. config file
 func Parse(stderr io.Writer, args []string) (Config, error) {
@@ -48,15 +106,36 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 	fs.StringVar(&cfg.Program, 'p', "program", "terraform", "The default program to use with pug.")
 	workdir := fs.String('w', "workdir", ".", "The working directory containing modules.")
 	fs.IntVar(&cfg.MaxTasks, 't', "max-tasks", 2*runtime.NumCPU(), "The maximum number of parallel tasks.")
-	fs.StringVar(&cfg.DataDir, 0, "data-dir", defaultDataDir, "Directory in which to store plan files.")
+	fs.StringVar(&cfg.DataDir, 0, "data-dir", defaultDataDir, "Directory in which to store plan files and caches.")
 	fs.StringListVar(&cfg.Envs, 'e', "env", "Environment variable to pass to terraform process. Can set more than once.")
 	fs.StringListVar(&cfg.Args, 'a', "arg", "CLI arg to pass to terraform process. Can set more than once.")
 	fs.BoolVar(&cfg.Debug, 'd', "debug", "Log bubbletea messages to messages.log")
 	fs.BoolVar(&cfg.Version, 'v', "version", "Print version.")
 	_ = fs.String('c', "config", defaultConfigFile, "Path to config file.")
+	fs.StringListVar(&cfg.Ignore.Include, 0, "include", "Only load modules with a path matching glob. Can set more than once.")
+	fs.StringListVar(&cfg.Ignore.Exclude, 0, "exclude", "Skip directories with a path matching glob. Can set more than once.")
+	fs.BoolVar(&cfg.Ignore.Gitignore, 0, "gitignore", "Skip directories ignored by .gitignore files.")
+	timeouts := fs.StringList(0, "timeout", "Timeout for tasks, optionally for a type of task, e.g. 1h or apply=2h. Can set more than once.")
+	retries := fs.Int(0, "retries", 0, "Number of times to retry a task that fails with a transient error.")
+	retryBackoff := fs.Duration(0, "retry-backoff", 10*time.Second, "Delay before retrying a task, doubling with each retry.")
+	retryPatterns := fs.StringList(0, "retry-pattern", "Regex matching output of a task failing with a transient error. Can set more than once.")
+	preHooks := fs.StringList(0, "pre-hook", "Program to run before a type of task, e.g. plan=tflint. Can set more than once.")
+	postHooks := fs.StringList(0, "post-hook", "Program to run after a type of task, e.g. apply=./notify.sh. Can set more than once.")
+	fs.StringListVar(&cfg.PTY, 0, "pty", "Run a type of task, or a program, in a pseudo-terminal, e.g. plan or terraform. Can set more than once.")
+	fs.BoolVar(&cfg.MirrorTaskOutput, 0, "mirror-task-output", "Mirror the output of every task to files in the data directory.")
+	protect := fs.StringList(0, "protect", "Protect workspaces matching <module>[:<workspace>] globs, e.g. prod/** or **:prod. Can set more than once.")
+	forbidAutoApply := fs.Bool(0, "forbid-auto-apply", "Forbid auto-applying protected workspaces; only apply reviewed plans.")
+	concurrencyLimits := fs.StringList(0, "concurrency-limit", "Max running tasks sharing a backend, path or env var, e.g. backend:s3=5. Can set more than once.")
+	fs.BoolVar(&cfg.DiscoverLocalModules, 0, "discover-local-modules", "Load root modules without a backend configuration, which use local state.")
 
 	fs.BoolVar(&cfg.DisableReloadAfterApply, 0, "disable-reload-after-apply", "Disable automatic reload of state following an apply.")
+	fs.BoolVar(&cfg.DisableWatch, 0, "disable-watch", "Disable automatic reload of modules and workspaces following changes to the working directory.")
 
+	var groupPolicy string
+	{
+		usage := fmt.Sprintf("Policy for a task group when one of its tasks fails (valid: %s).", strings.Join(task.GroupPolicies, ","))
+		fs.StringEnumVar(&groupPolicy, 0, "group-policy", usage, task.GroupPolicies...)
+	}
 	{
 		usage := fmt.Sprintf("Logging level (valid: %s).", strings.Join(logging.ValidLevels(), ","))
 		fs.StringEnumVar(&cfg.Logging.Level, 'l', "log-level", usage, logging.ValidLevels()...)
@@ -69,7 +148,10 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 	err = ff.Parse(fs, args,
 		ff.WithEnvVarPrefix("PUG"),
 		ff.WithConfigFileFlag("config"),
-		ff.WithConfigFileParser(ffyaml.Parse),
+		ff.WithConfigFileParser(parseConfigFile(map[string]any{
+			"workflows": &cfg.Workflows,
+			"policies":  &cfg.Policies,
+		})),
 		ff.WithConfigAllowMissingFile(),
 	)
 	if err != nil {
@@ -91,6 +173,70 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	cfg.Timeouts, err = task.ParseTimeouts(*timeouts, taskIdentifiers)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Retry, err = task.NewRetryPolicy(*retries, *retryBackoff, *retryPatterns)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.ConcurrencyLimits, err = task.ParseConcurrencyLimits(*concurrencyLimits)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Hooks, err = task.ParseHooks(*preHooks, *postHooks, taskIdentifiers)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.GroupPolicy = task.GroupPolicy(groupPolicy)
+	cfg.Protection, err = workspace.ParseProtection(*protect, *forbidAutoApply)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := workflow.ValidateAll(cfg.Workflows); err != nil {
+		return Config{}, err
+	}
+	if err := plan.ValidatePolicies(cfg.Policies); err != nil {
+		return Config{}, err
+	}
 
 	return cfg, nil
 }
+
+// parseConfigFile returns a parser for the YAML config file. Some config,
+// such as workflows and policies, is not flags, so it is decoded separately,
+// each top-level key into its given destination, and the remainder of the
+// config file is parsed for flags.
+func parseConfigFile(decoded map[string]any) ff.ConfigFileParseFunc {
+	return func(r io.Reader, set func(name, value string) error) error {
+		var doc yaml.Node
+		if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+			root := doc.Content[0]
+			// Mapping nodes alternate between key and value nodes.
+			for i := 0; i < len(root.Content); {
+				key := root.Content[i].Value
+				dst, ok := decoded[key]
+				if !ok {
+					i += 2
+					continue
+				}
+				if err := root.Content[i+1].Decode(dst); err != nil {
+					return fmt.Errorf("parsing %s: %w", key, err)
+				}
+				root.Content = slices.Delete(root.Content, i, i+2)
+			}
+		}
+		remainder, err := yaml.Marshal(&doc)
+		if err != nil {
+			return err
+		}
+		return ffyaml.Parse(bytes.NewReader(remainder), set)
+	}
+}
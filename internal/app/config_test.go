@@ -8,10 +8,14 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/testutils"
+	"github.com/leg100/pug/internal/workflow"
 	"github.com/peterbourgon/ff/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -51,6 +55,7 @@ func TestConfig(t *testing.T) {
 					Logging: logging.Options{
 						Level: "info",
 					},
+					GroupPolicy: task.ContinueGroupPolicy,
 				}
 				assert.Equal(t, want, got)
 			},
@@ -148,6 +153,160 @@ func TestConfig(t *testing.T) {
 				assert.Contains(t, got.Envs, "TF_PLUGIN_CACHE_DIR=/tmp")
 			},
 		},
+		{
+			"config file with include and exclude globs",
+			"include:\n- stacks/**\nexclude:\n- examples\n- '**/fixtures'\ngitignore: true\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, []string{"stacks/**"}, got.Ignore.Include)
+				assert.Equal(t, []string{"examples", "**/fixtures"}, got.Ignore.Exclude)
+				assert.True(t, got.Ignore.Gitignore)
+			},
+		},
+		{
+			"config file with timeouts",
+			"timeout:\n- 1h\n- apply=2h\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, time.Hour, got.Timeouts.Default)
+				assert.Equal(t, map[task.Identifier]time.Duration{"apply": 2 * time.Hour}, got.Timeouts.Identifiers)
+			},
+		},
+		{
+			"retry with default patterns",
+			"",
+			[]string{"--retries", "2"},
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, 3, got.Retry.MaxAttempts)
+				assert.Equal(t, 10*time.Second, got.Retry.Backoff)
+				assert.Len(t, got.Retry.Patterns, len(task.DefaultRetryPatterns))
+			},
+		},
+		{
+			"config file with retry patterns",
+			"retries: 1\nretry-backoff: 1m\nretry-pattern:\n- 'Error: 503'\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, 2, got.Retry.MaxAttempts)
+				assert.Equal(t, time.Minute, got.Retry.Backoff)
+				if assert.Len(t, got.Retry.Patterns, 1) {
+					assert.Equal(t, "Error: 503", got.Retry.Patterns[0].String())
+				}
+			},
+		},
+		{
+			"group policy",
+			"",
+			[]string{"--group-policy", "fail-fast"},
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, task.FailFastGroupPolicy, got.GroupPolicy)
+			},
+		},
+		{
+			"config file with workflows",
+			"max-tasks: 3\nworkflows:\n- name: check\n  steps:\n  - init\n  - exec: tflint\n  - plan\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				want := []workflow.Workflow{
+					{
+						Name: "check",
+						Steps: []workflow.Step{
+							{Action: workflow.InitAction},
+							{Exec: "tflint"},
+							{Action: workflow.PlanAction},
+						},
+					},
+				}
+				assert.Equal(t, want, got.Workflows)
+				assert.Equal(t, 3, got.MaxTasks)
+			},
+		},
+		{
+			"config file with concurrency limits",
+			"concurrency-limit:\n- backend:s3=5\n- env:AWS_PROFILE=3\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				want := task.ConcurrencyLimits{
+					{Kind: task.BackendConcurrency, Pattern: "s3", Limit: 5},
+					{Kind: task.EnvConcurrency, Pattern: "AWS_PROFILE", Limit: 3},
+				}
+				assert.Equal(t, want, got.ConcurrencyLimits)
+			},
+		},
+		{
+			"config file with pty",
+			"pty:\n- plan\n- terraform\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, []string{"plan", "terraform"}, got.PTY)
+			},
+		},
+		{
+			"config file with hooks",
+			"pre-hook:\n- plan=tflint --format compact\npost-hook:\n- apply=./notify.sh\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				want := task.Hooks{
+					Pre: map[task.Identifier][]task.Execution{
+						"plan": {{Program: "tflint", Args: []string{"--format", "compact"}}},
+					},
+					Post: map[task.Identifier][]task.Execution{
+						"apply": {{Program: "./notify.sh", Args: []string{}}},
+					},
+				}
+				assert.Equal(t, want, got.Hooks)
+			},
+		},
+		{
+			"config file with mirror-task-output",
+			"mirror-task-output: true\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.True(t, got.MirrorTaskOutput)
+			},
+		},
+		{
+			"config file with protected workspaces",
+			"protect:\n- prod/**\n- '**:prod'\nforbid-auto-apply: true\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, []string{"prod/**", "**:prod"}, got.Protection.Patterns)
+				assert.True(t, got.Protection.ForbidAutoApply)
+			},
+		},
+		{
+			"config file with policies",
+			"max-tasks: 3\npolicies:\n- name: no-prod-deletions\n  workspaces:\n  - '**:prod'\n  actions:\n  - delete\n- name: max-changes\n  max_changes: 10\nworkflows:\n- name: check\n  steps:\n  - plan\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				want := []plan.Policy{
+					{
+						Name:       "no-prod-deletions",
+						Workspaces: []string{"**:prod"},
+						Actions:    []plan.ChangeAction{plan.DeleteAction},
+					},
+					{
+						Name:       "max-changes",
+						MaxChanges: 10,
+					},
+				}
+				assert.Equal(t, want, got.Policies)
+				assert.Len(t, got.Workflows, 1)
+				assert.Equal(t, 3, got.MaxTasks)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
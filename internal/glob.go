@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether the slash-separated path matches the glob. The
+// glob uses the syntax of path.Match, with the addition of `**`, which matches
+// zero or more directories.
+func MatchGlob(glob, name string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(glob, name []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			glob = glob[1:]
+			if len(glob) == 0 {
+				// A trailing ** matches everything beneath.
+				return len(name) > 0
+			}
+			for i := range len(name) + 1 {
+				if matchSegments(glob, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], name[0]); !ok {
+			return false
+		}
+		glob, name = glob[1:], name[1:]
+	}
+	return len(name) == 0
+}
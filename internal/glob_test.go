@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		name string
+		want bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "bar", false},
+		{"foo/*", "foo/bar", true},
+		{"foo/*", "foo/bar/baz", false},
+		{"**/foo", "foo", true},
+		{"**/foo", "a/b/foo", true},
+		{"**/foo", "a/b/foo/bar", false},
+		{"foo/**", "foo", false},
+		{"foo/**", "foo/bar/baz", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+		{"examples/*/fixtures", "examples/aws/fixtures", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchGlob(tt.glob, tt.name))
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// detectionCache caches the results of detecting backends in terraform and
+// terragrunt files, avoiding the need to re-parse files that haven't changed
+// since the last time pug searched for modules. Files are deemed unchanged if
+// their modification time and size are unchanged.
+//
+// The cache is persisted to a file in the data directory, one per working
+// directory.
+type detectionCache struct {
+	// path to file in which to persist cache; if empty the cache is not
+	// persisted.
+	path string
+
+	mu      sync.Mutex
+	entries map[string]detectionResult
+	// paths of files looked up since the cache was loaded
+	seen map[string]struct{}
+}
+
+type detectionResult struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+
+	fileDetection
+}
+
+// newDetectionCache constructs a cache for the given working directory,
+// persisted within the given data directory. If dataDir is empty then the cache
+// is not persisted.
+func newDetectionCache(dataDir, workdir string) *detectionCache {
+	c := &detectionCache{
+		entries: make(map[string]detectionResult),
+		seen:    make(map[string]struct{}),
+	}
+	if dataDir != "" {
+		sum := sha256.Sum256([]byte(workdir))
+		c.path = filepath.Join(dataDir, "cache", fmt.Sprintf("modules-%x.json", sum[:8]))
+	}
+	return c
+}
+
+// load loads the cache from disk. A missing cache file is not an error.
+func (c *detectionCache) load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	entries := make(map[string]detectionResult)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("decoding module cache: %w", err)
+	}
+	c.entries = entries
+	return nil
+}
+
+// save persists the cache to disk, discarding entries for files that haven't
+// been looked up since the cache was last saved, i.e. files that no longer
+// exist or are ignored. It should only be called following a search of the
+// entire working directory.
+func (c *detectionCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		if _, ok := c.seen[path]; !ok {
+			delete(c.entries, path)
+		}
+	}
+	clear(c.seen)
+	return c.write()
+}
+
+// update persists the cache to disk without discarding any entries, for use
+// following a search of only some directories.
+func (c *detectionCache) update() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.write()
+}
+
+// write writes the cache to disk. The caller must hold the lock.
+func (c *detectionCache) write() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it, to avoid leaving behind a
+	// partially written cache.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// detectFile wraps the package function of the same name, returning the
+// cached result if the file is unchanged.
+func (c *detectionCache) detectFile(path string, info fs.FileInfo) (fileDetection, error) {
+	c.mu.Lock()
+	c.seen[path] = struct{}{}
+	result, ok := c.entries[path]
+	c.mu.Unlock()
+
+	if ok && result.ModTime.Equal(info.ModTime()) && result.Size == info.Size() {
+		return result.fileDetection, nil
+	}
+	detection, err := detectFile(path)
+	if err != nil {
+		// Don't cache errors; the file is re-parsed next time.
+		return fileDetection{}, err
+	}
+	c.mu.Lock()
+	c.entries[path] = detectionResult{
+		ModTime:       info.ModTime(),
+		Size:          info.Size(),
+		fileDetection: detection,
+	}
+	c.mu.Unlock()
+	return detection, nil
+}
@@ -0,0 +1,111 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectionCache(t *testing.T) {
+	workdir := internal.NewTestWorkdir(t)
+	dataDir := t.TempDir()
+
+	path := workdir.Join("main.tf")
+	err := os.WriteFile(path, []byte("terraform {\n  backend \"local\" {}\n}\n"), 0o644)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	cache := newDetectionCache(dataDir, workdir.String())
+	got, err := cache.detectFile(path, info)
+	require.NoError(t, err)
+	assert.Equal(t, fileDetection{Backend: "local", Found: true}, got)
+	require.NoError(t, cache.save())
+
+	// Load cache from disk and check result is retrieved from cache and not
+	// from the file, by removing the file.
+	cache = newDetectionCache(dataDir, workdir.String())
+	require.NoError(t, cache.load())
+	require.NoError(t, os.Remove(path))
+	got, err = cache.detectFile(path, info)
+	require.NoError(t, err)
+	assert.Equal(t, fileDetection{Backend: "local", Found: true}, got)
+
+	// Alter file, which should invalidate the cached result.
+	err = os.WriteFile(path, []byte("terraform {\n  backend \"s3\" {}\n}\n"), 0o644)
+	require.NoError(t, err)
+	err = os.Chtimes(path, time.Time{}, info.ModTime().Add(time.Second))
+	require.NoError(t, err)
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	got, err = cache.detectFile(path, info)
+	require.NoError(t, err)
+	assert.Equal(t, fileDetection{Backend: "s3", Found: true}, got)
+
+	// Entries for files not looked up since the last save are discarded.
+	require.NoError(t, cache.save())
+	require.NoError(t, cache.save())
+	assert.Empty(t, cache.entries)
+}
+
+// BenchmarkFind benchmarks searching a large generated tree of directories
+// for modules, both with an empty cache and with a cache populated by a
+// previous search.
+func BenchmarkFind(b *testing.B) {
+	workdir, err := internal.NewWorkdir(b.TempDir())
+	require.NoError(b, err)
+
+	const (
+		stacks  = 100
+		modules = 20
+		files   = 10
+	)
+	backend := []byte("terraform {\n  backend \"local\" {}\n}\n")
+	resource := []byte("resource \"null_resource\" \"foo\" {\n  triggers = {\n    foo = \"bar\"\n  }\n}\n")
+	for i := range stacks {
+		for j := range modules {
+			dir := workdir.Join(fmt.Sprintf("stack-%d", i), fmt.Sprintf("module-%d", j))
+			require.NoError(b, os.MkdirAll(dir, 0o755))
+			for k := range files {
+				content := resource
+				// Only every other directory is a root module.
+				if j%2 == 0 && k == files-1 {
+					content = backend
+				}
+				err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file-%d.tf", k)), content, 0o644)
+				require.NoError(b, err)
+			}
+		}
+	}
+
+	search := func(b *testing.B, cache *detectionCache) {
+		ch, errc := find(context.Background(), workdir, newIgnorer(workdir, IgnoreOptions{}), cache, false)
+		var n int
+		for range ch {
+			n++
+		}
+		require.NoError(b, <-errc)
+		require.Equal(b, stacks*modules/2, n)
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		for range b.N {
+			search(b, newDetectionCache("", workdir.String()))
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cache := newDetectionCache("", workdir.String())
+		search(b, cache)
+		b.ResetTimer()
+		for range b.N {
+			search(b, cache)
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package module
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+)
+
+const FormatCheckTask task.Identifier = "fmt-check"
+
+// FormatDiff is the change `terraform fmt` would make to a file in order to
+// format it.
+type FormatDiff struct {
+	// Path to the file relative to the module.
+	Path string
+	// Diff is the unified diff of the change.
+	Diff string
+}
+
+// FormatCheckSummary summarises the outcome of checking the formatting of a
+// module.
+type FormatCheckSummary struct {
+	Files int
+}
+
+func (s FormatCheckSummary) String() string {
+	switch s.Files {
+	case 0:
+		return "formatted"
+	case 1:
+		return "1 file needs formatting"
+	default:
+		return fmt.Sprintf("%d files need formatting", s.Files)
+	}
+}
+
+// Format formats the files in the module's directory, i.e. `terraform fmt`. If
+// the module has been checked then it is checked again, because files in its
+// subdirectories may still need formatting.
+func (s *Service) Format(moduleID resource.ID) (task.Spec, error) {
+	spec, err := s.format(moduleID, false)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	spec.AfterExited = func(*task.Task) {
+		mod, err := s.table.Get(moduleID)
+		if err != nil || mod.FormatDiffs == nil {
+			return
+		}
+		spec, err := s.FormatCheck(moduleID)
+		if err == nil {
+			_, err = s.tasks.Create(spec)
+		}
+		if err != nil {
+			s.logger.Error("checking formatting", "error", err, "module", mod)
+		}
+	}
+	return spec, nil
+}
+
+// FormatRecursive formats the files in the module's directory and its
+// subdirectories, i.e. `terraform fmt -recursive`, which are the files checked
+// by FormatCheck. Files belonging to modules beneath the module are formatted
+// too, so their changes are cleared along with the module's.
+func (s *Service) FormatRecursive(moduleID resource.ID) (task.Spec, error) {
+	spec, err := s.format(moduleID, true)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	spec.AfterExited = func(*task.Task) {
+		mod, err := s.table.Get(moduleID)
+		if err != nil {
+			return
+		}
+		for _, other := range s.table.List() {
+			if within(other.Path, mod.Path) {
+				s.table.Update(other.ID, func(existing *Module) error {
+					existing.FormatDiffs = nil
+					return nil
+				})
+			}
+		}
+	}
+	return spec, nil
+}
+
+func (s *Service) format(moduleID resource.ID, recursive bool) (task.Spec, error) {
+	mod, err := s.table.Get(moduleID)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	spec := task.Spec{
+		ModuleID: &mod.ID,
+		Path:     mod.Path,
+		Execution: task.Execution{
+			TerraformCommand: []string{"fmt"},
+		},
+		Immediate: true,
+		Short:     true,
+	}
+	if recursive {
+		spec.Execution.Args = []string{"-recursive"}
+	}
+	return spec, nil
+}
+
+// FormatCheck checks whether files in the module need formatting, without
+// rewriting them. The changes that formatting would make are recorded on the
+// module for review. Files in subdirectories are checked too, excluding those
+// belonging to modules beneath the module, which are left to their own checks.
+func (s *Service) FormatCheck(moduleID resource.ID) (task.Spec, error) {
+	mod, err := s.table.Get(moduleID)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	spec := task.Spec{
+		ModuleID:   &mod.ID,
+		Path:       mod.Path,
+		Identifier: FormatCheckTask,
+		Execution: task.Execution{
+			TerraformCommand: []string{"fmt"},
+			Args:             []string{"-check", "-diff", "-recursive"},
+		},
+		// fmt exits with 3 if files need formatting, which is the purpose of
+		// the check rather than a failure.
+		SuccessExitCodes: []int{3},
+		Description:      "fmt check",
+		Immediate:        true,
+		Short:            true,
+		BeforeExited: func(t *task.Task) (task.Summary, error) {
+			diffs, err := parseFormatDiffs(t.NewReader(false))
+			if err != nil {
+				return nil, err
+			}
+			diffs = slices.DeleteFunc(diffs, func(diff FormatDiff) bool {
+				return s.ownedByChild(mod, diff.Path)
+			})
+			s.table.Update(moduleID, func(existing *Module) error {
+				existing.FormatDiffs = diffs
+				return nil
+			})
+			return FormatCheckSummary{Files: len(diffs)}, nil
+		},
+	}
+	return spec, nil
+}
+
+// ownedByChild returns true if the file, relative to the module, belongs to
+// another module beneath the module.
+func (s *Service) ownedByChild(mod *Module, file string) bool {
+	dir := filepath.Join(mod.Path, filepath.Dir(file))
+	for _, other := range s.table.List() {
+		if other.Path != mod.Path && within(other.Path, mod.Path) && within(dir, other.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// within returns true if the path is the same as, or beneath, the directory,
+// both relative to the working directory.
+func within(path, dir string) bool {
+	if dir == "." {
+		return true
+	}
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// parseFormatDiffs parses the output of `terraform fmt -check -diff`, which
+// lists each file that needs formatting followed by its diff, e.g.:
+//
+//	main.tf
+//	--- old/main.tf
+//	+++ new/main.tf
+//	@@ -1,3 +1,3 @@
+//	...
+func parseFormatDiffs(r io.Reader) ([]FormatDiff, error) {
+	var (
+		lines   []string
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	var (
+		diffs []FormatDiff
+		diff  strings.Builder
+	)
+	finish := func() {
+		if len(diffs) > 0 {
+			diffs[len(diffs)-1].Diff = diff.String()
+		}
+		diff.Reset()
+	}
+	for i, line := range lines {
+		// A file name followed by the header of its diff starts a new diff.
+		if i+1 < len(lines) && lines[i+1] == "--- old/"+line {
+			finish()
+			diffs = append(diffs, FormatDiff{Path: line})
+			continue
+		}
+		if len(diffs) > 0 {
+			diff.WriteString(line)
+			diff.WriteRune('\n')
+		}
+	}
+	finish()
+	return diffs, nil
+}
@@ -0,0 +1,94 @@
+package module
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormatDiffs(t *testing.T) {
+	out := `main.tf
+--- old/main.tf
++++ new/main.tf
+@@ -1,3 +1,3 @@
+ resource "random_pet" "pet" {
+-  length=2
++  length = 2
+ }
+modules/vpc/outputs.tf
+--- old/modules/vpc/outputs.tf
++++ new/modules/vpc/outputs.tf
+@@ -1,2 +1,2 @@
+-output "id" { value = "x"  }
++output "id" { value = "x" }
+`
+	got, err := parseFormatDiffs(strings.NewReader(out))
+	require.NoError(t, err)
+
+	want := []FormatDiff{
+		{
+			Path: "main.tf",
+			Diff: `--- old/main.tf
++++ new/main.tf
+@@ -1,3 +1,3 @@
+ resource "random_pet" "pet" {
+-  length=2
++  length = 2
+ }
+`,
+		},
+		{
+			Path: "modules/vpc/outputs.tf",
+			Diff: `--- old/modules/vpc/outputs.tf
++++ new/modules/vpc/outputs.tf
+@@ -1,2 +1,2 @@
+-output "id" { value = "x"  }
++output "id" { value = "x" }
+`,
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestParseFormatDiffs_Formatted(t *testing.T) {
+	got, err := parseFormatDiffs(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestFormatCheckSummary(t *testing.T) {
+	assert.Equal(t, "formatted", FormatCheckSummary{}.String())
+	assert.Equal(t, "1 file needs formatting", FormatCheckSummary{Files: 1}.String())
+	assert.Equal(t, "2 files need formatting", FormatCheckSummary{Files: 2}.String())
+}
+
+func TestService_ownedByChild(t *testing.T) {
+	root := New(Options{Path: "."})
+	envs := New(Options{Path: "envs"})
+	prod := New(Options{Path: "envs/prod"})
+	svc := &Service{
+		table: &fakeModuleTable{modules: []*Module{root, envs, prod}},
+	}
+
+	tests := []struct {
+		name string
+		mod  *Module
+		file string
+		want bool
+	}{
+		{"file in module directory", envs, "main.tf", false},
+		{"file in non-module subdirectory", envs, "modules/vpc/main.tf", false},
+		{"file in child module", envs, "prod/main.tf", true},
+		{"file in subdirectory of child module", envs, "prod/modules/db/main.tf", true},
+		{"file in sibling with child's prefix", envs, "production/main.tf", false},
+		{"file in child of root module", root, "envs/main.tf", true},
+		{"file in leaf module", prod, "main.tf", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, svc.ownedByChild(tt.mod, tt.file))
+		})
+	}
+}
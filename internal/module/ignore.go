@@ -0,0 +1,226 @@
+package module
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/leg100/pug/internal"
+)
+
+const (
+	// pugignoreFile is the name of a file containing patterns of paths to
+	// ignore when searching for modules. It uses the same format as a
+	// .gitignore file.
+	pugignoreFile = ".pugignore"
+	gitignoreFile = ".gitignore"
+)
+
+// ignorer determines which directories to ignore when searching for modules.
+// Patterns are read from ignore files found in the working directory and its
+// descendents, with patterns in an ignore file applying to paths relative to
+// the directory containing the file, following gitignore semantics. In
+// addition, directories can be excluded, and modules included, using globs
+// relative to the working directory.
+type ignorer struct {
+	workdir internal.Workdir
+	// names of ignore files to read
+	filenames []string
+	// globs of module paths to include; if empty then all modules are included.
+	include []string
+	// globs of directories to exclude.
+	exclude []string
+	// patterns read from ignore files, keyed by the path of the directory
+	// containing the ignore files, relative to the working directory.
+	patterns map[string][]ignorePattern
+	// mu guards access to patterns
+	mu sync.Mutex
+}
+
+// IgnoreOptions are options for ignoring paths when searching for modules.
+type IgnoreOptions struct {
+	// Include is a list of globs. If non-empty then only modules with a path
+	// matching a glob are loaded.
+	Include []string
+	// Exclude is a list of globs. Directories with a path matching a glob are
+	// skipped, along with their descendents.
+	Exclude []string
+	// Gitignore additionally reads patterns from .gitignore files.
+	Gitignore bool
+}
+
+func newIgnorer(workdir internal.Workdir, opts IgnoreOptions) *ignorer {
+	filenames := []string{pugignoreFile}
+	if opts.Gitignore {
+		filenames = append(filenames, gitignoreFile)
+	}
+	return &ignorer{
+		workdir:   workdir,
+		filenames: filenames,
+		include:   opts.Include,
+		exclude:   opts.Exclude,
+		patterns:  make(map[string][]ignorePattern),
+	}
+}
+
+// isIgnoreFile determines whether a file with the given name is an ignore
+// file.
+func (ig *ignorer) isIgnoreFile(name string) bool {
+	for _, fname := range ig.filenames {
+		if name == fname {
+			return true
+		}
+	}
+	return false
+}
+
+// skip determines whether the directory with the given path relative to the
+// working directory should be skipped. Its parent directories are assumed to
+// have already been checked.
+func (ig *ignorer) skip(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return false
+	}
+	for _, glob := range ig.exclude {
+		if internal.MatchGlob(strings.TrimSuffix(glob, "/"), rel) {
+			return true
+		}
+	}
+	// Check patterns from ignore files in each parent directory, starting with
+	// the working directory. Patterns in ignore files further down take
+	// precedence, and a later pattern within a file takes precedence over an
+	// earlier one.
+	var (
+		ignored bool
+		dir     = "."
+		parts   = strings.Split(rel, "/")
+	)
+	for i := range parts {
+		for _, p := range ig.load(dir) {
+			target := strings.Join(parts[i:], "/")
+			if p.match(target) {
+				ignored = !p.negate
+			}
+		}
+		dir = path.Join(dir, parts[i])
+	}
+	return ignored
+}
+
+// ignored determines whether the directory with the given path relative to
+// the working directory is ignored, either because it is to be skipped or
+// because one of its parent directories is to be skipped.
+func (ig *ignorer) ignored(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		if ig.skip(strings.Join(parts[:i+1], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// included determines whether a module with the given path relative to the
+// working directory should be included.
+func (ig *ignorer) included(rel string) bool {
+	if len(ig.include) == 0 {
+		return true
+	}
+	rel = filepath.ToSlash(rel)
+	for _, glob := range ig.include {
+		if internal.MatchGlob(strings.TrimSuffix(glob, "/"), rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// load retrieves patterns from ignore files in the directory with the given
+// path relative to the working directory, reading the files if they haven't
+// already been read.
+func (ig *ignorer) load(dir string) []ignorePattern {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+
+	if patterns, ok := ig.patterns[dir]; ok {
+		return patterns
+	}
+	var patterns []ignorePattern
+	for _, fname := range ig.filenames {
+		patterns = append(patterns, readIgnoreFile(ig.workdir.Join(dir, fname))...)
+	}
+	ig.patterns[dir] = patterns
+	return patterns
+}
+
+// ignorePattern is a pattern from an ignore file.
+type ignorePattern struct {
+	// glob is the pattern, relative to the directory containing the ignore
+	// file.
+	glob string
+	// negate is true if the pattern re-includes a path that has been ignored by
+	// a previous pattern.
+	negate bool
+}
+
+// readIgnoreFile reads patterns from an ignore file. A missing or unreadable
+// file yields no patterns.
+func readIgnoreFile(name string) (patterns []ignorePattern) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p, ok := parseIgnorePattern(scanner.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// parseIgnorePattern parses a line from an ignore file, returning false if the
+// line does not contain a pattern.
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+	var p ignorePattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	// Only directories are of interest, so a trailing slash, which restricts
+	// a pattern to matching only directories, can be dropped.
+	line = strings.TrimSuffix(line, "/")
+	if line == "" {
+		return ignorePattern{}, false
+	}
+	if strings.Contains(line, "/") {
+		// A pattern containing a slash is relative to the directory containing
+		// the ignore file.
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		// Otherwise the pattern matches at any level beneath the directory.
+		line = "**/" + line
+	}
+	p.glob = line
+	return p, true
+}
+
+func (p ignorePattern) match(rel string) bool {
+	return internal.MatchGlob(p.glob, rel)
+}
@@ -0,0 +1,108 @@
+package module
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leg100/pug/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIgnorePattern(t *testing.T) {
+	tests := []struct {
+		line string
+		want ignorePattern
+		ok   bool
+	}{
+		{"", ignorePattern{}, false},
+		{"# comment", ignorePattern{}, false},
+		{"foo", ignorePattern{glob: "**/foo"}, true},
+		{"foo/", ignorePattern{glob: "**/foo"}, true},
+		{"/foo", ignorePattern{glob: "foo"}, true},
+		{"foo/bar", ignorePattern{glob: "foo/bar"}, true},
+		{"!foo", ignorePattern{glob: "**/foo", negate: true}, true},
+		{`\!foo`, ignorePattern{glob: "**/!foo"}, true},
+		{`\#foo`, ignorePattern{glob: "**/#foo"}, true},
+		{"foo   ", ignorePattern{glob: "**/foo"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseIgnorePattern(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFindModules_Ignore(t *testing.T) {
+	workdir := internal.NewTestWorkdir(t)
+
+	backend := []byte(`
+terraform {
+  backend "local" {}
+}
+`)
+	for _, path := range []string{
+		"prod",
+		"dev",
+		"examples/basic",
+		"examples/advanced",
+		"vendor/modules/stack",
+		"archived/old",
+		"archived/keep",
+		"stacks/a/fixtures",
+	} {
+		err := os.MkdirAll(workdir.Join(path), 0o755)
+		require.NoError(t, err)
+		err = os.WriteFile(workdir.Join(path, "main.tf"), backend, 0o644)
+		require.NoError(t, err)
+	}
+	writeFile := func(path, content string) {
+		err := os.WriteFile(workdir.Join(path), []byte(content), 0o644)
+		require.NoError(t, err)
+	}
+	writeFile(".pugignore", "# ignore examples\nexamples/\narchived/*\n!archived/keep\n")
+	writeFile(".gitignore", "vendor\n")
+	writeFile(filepath.Join("stacks", ".pugignore"), "fixtures\n")
+
+	tests := []struct {
+		name string
+		opts IgnoreOptions
+		want []string
+	}{
+		{
+			name: "pugignore",
+			want: []string{"prod", "dev", "vendor/modules/stack", "archived/keep"},
+		},
+		{
+			name: "gitignore",
+			opts: IgnoreOptions{Gitignore: true},
+			want: []string{"prod", "dev", "archived/keep"},
+		},
+		{
+			name: "exclude",
+			opts: IgnoreOptions{Exclude: []string{"vendor", "archived/**"}},
+			want: []string{"prod", "dev"},
+		},
+		{
+			name: "include",
+			opts: IgnoreOptions{Include: []string{"prod", "vendor/**"}},
+			want: []string{"prod", "vendor/modules/stack"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modules, errc := find(context.Background(), workdir, newIgnorer(workdir, tt.opts), newDetectionCache("", workdir.String()), false)
+
+			var got []string
+			for opts := range modules {
+				got = append(got, filepath.ToSlash(opts.Path))
+			}
+			assert.ElementsMatch(t, tt.want, got)
+			assert.NoError(t, <-errc)
+		})
+	}
+}
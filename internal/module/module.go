@@ -2,9 +2,13 @@ package module
 
 import (
 	"context"
+	"errors"
 	"io/fs"
 	"log/slog"
+	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"sync"
 
 	"github.com/hashicorp/hcl/v2"
@@ -25,6 +29,14 @@ type Module struct {
 
 	// The module's backend type
 	Backend string
+	// Terragrunt is the module's terragrunt configuration, or nil if the module
+	// is not a terragrunt module.
+	Terragrunt *TerragruntConfig
+
+	// FormatDiffs are the changes `terraform fmt` would make to the module's
+	// files, as found by the most recent format check. Nil if the module's
+	// files are formatted or have not been checked.
+	FormatDiffs []FormatDiff
 
 	// Dependencies on other modules
 	dependencies []resource.ID
@@ -36,14 +48,17 @@ type Options struct {
 	Path string
 	// Backend is the type of terraform backend
 	Backend string
+	// Terragrunt is the terragrunt configuration, if any.
+	Terragrunt *TerragruntConfig
 }
 
 // New constructs a module.
 func New(opts Options) *Module {
 	return &Module{
-		ID:      resource.NewID(resource.Module),
-		Path:    opts.Path,
-		Backend: opts.Backend,
+		ID:         resource.NewID(resource.Module),
+		Path:       opts.Path,
+		Backend:    opts.Backend,
+		Terragrunt: opts.Terragrunt,
 	}
 }
 
@@ -61,101 +76,221 @@ func (m *Module) Dependencies() []resource.ID {
 	return m.dependencies
 }
 
+// findWorkers is the number of workers searching directories for modules.
+var findWorkers = 2 * runtime.NumCPU()
+
 // find finds root modules that are descendents of the workdir and
 // returns options for creating equivalent pug modules.
 //
 // A root module is deemed to be a directory that contains a .tf file that
 // contains a backend or cloud block, or in the case of terragrunt, a
-// terragrunt.hcl file.
+// terragrunt.hcl file. If local is true then directories that are deemed to be
+// root modules using the local backend by default are also returned (see
+// detectModule).
 //
 // find returns two channels: the first streams discovered modules (in the form
 // of Options structs for creating the module in pug); the second streams any
 // errors encountered.
 //
+// Directories and modules are skipped according to the ignorer. Directories
+// are searched concurrently by a pool of workers, with the results of parsing
+// files retrieved from the cache where possible.
+//
 // When finished, both channels are closed.
-func find(ctx context.Context, workdir internal.Workdir) (<-chan Options, <-chan error) {
+func find(ctx context.Context, workdir internal.Workdir, ig *ignorer, cache *detectionCache, local bool) (<-chan Options, <-chan error) {
 	modules := make(chan Options)
 	errc := make(chan error, 1)
 
-	go func() {
-		var wg sync.WaitGroup
-		err := filepath.WalkDir(workdir.String(), func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				errc <- err
-				return err
-			}
-			if d.IsDir() {
-				switch d.Name() {
-				case ".terraform", ".terragrunt-cache":
-					return filepath.SkipDir
-				}
-				return nil
-			}
+	w := &walker{
+		queue:   []string{workdir.String()},
+		pending: 1,
+	}
+	w.cond = sync.NewCond(&w.mu)
 
-			var isTerragrunt bool
-			switch {
-			case d.Name() == "terragrunt.hcl":
-				isTerragrunt = true
-				fallthrough
-			case filepath.Ext(path) == ".tf":
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					backend, found, err := detectBackend(path)
-					if err != nil {
-						errc <- err
-						return
-					}
-					if !isTerragrunt && !found {
-						// Not a terragrunt module, nor a vanilla terraform module with a
-						// backend config, so skip.
-						return
+	var wg sync.WaitGroup
+	for range findWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				dir, ok := w.next()
+				if !ok {
+					return
+				}
+				subdirs, err := func() ([]string, error) {
+					// Abort search if context canceled
+					if err := ctx.Err(); err != nil {
+						return nil, err
 					}
-					// Strip workdir from module path
-					stripped, err := filepath.Rel(workdir.String(), filepath.Dir(path))
+					entries, err := os.ReadDir(dir)
 					if err != nil {
-						errc <- err
-						return
+						return nil, err
+					}
+					var subdirs []string
+					for _, entry := range entries {
+						if !entry.IsDir() {
+							continue
+						}
+						switch entry.Name() {
+						case ".terraform", ".terragrunt-cache":
+							continue
+						}
+						path := filepath.Join(dir, entry.Name())
+						rel, err := workdir.Rel(path)
+						if err != nil {
+							return nil, err
+						}
+						if ig.skip(rel) {
+							continue
+						}
+						subdirs = append(subdirs, path)
 					}
-					modules <- Options{
-						Path:    stripped,
-						Backend: backend,
+					opts, found, err := detectModule(workdir, dir, entries, cache, local)
+					if found && ig.included(opts.Path) {
+						modules <- opts
 					}
+					return subdirs, err
 				}()
+				if err != nil {
+					errc <- err
+				}
+				w.done(subdirs)
 			}
-			// Abort walk if context canceled
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-				return nil
-			}
-		})
-		if err != nil {
-			errc <- err
-		}
-		go func() {
-			wg.Wait()
-			close(modules)
-			close(errc)
 		}()
+	}
+	go func() {
+		wg.Wait()
+		close(modules)
+		close(errc)
 	}()
 	return modules, errc
 }
 
-type terragrunt struct {
-	RemoteState *terragruntRemoteState `hcl:"remote_state,block"`
-	Remain      hcl.Body               `hcl:",remain"`
+// walker is a queue of directories to be searched.
+type walker struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// directories waiting to be searched
+	queue []string
+	// number of directories either waiting to be searched or being searched.
+	pending int
+}
+
+// next retrieves the next directory to search, waiting until one is
+// available. False is returned once there are no more directories to search.
+func (w *walker) next() (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for len(w.queue) == 0 && w.pending > 0 {
+		w.cond.Wait()
+	}
+	if len(w.queue) == 0 {
+		return "", false
+	}
+	dir := w.queue[len(w.queue)-1]
+	w.queue = w.queue[:len(w.queue)-1]
+	return dir, true
+}
+
+// done marks a directory as searched, queueing its sub-directories for
+// searching.
+func (w *walker) done(subdirs []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.queue = append(w.queue, subdirs...)
+	w.pending += len(subdirs) - 1
+	w.cond.Broadcast()
 }
 
-type terragruntRemoteState struct {
-	Backend string   `hcl:"backend,attr"`
-	Remain  hcl.Body `hcl:",remain"`
+// detectModule detects whether the directory at the given path is a root
+// module, returning options for creating the equivalent pug module if so. The
+// entries are the contents of the directory. The backend of a terragrunt
+// module is determined by resolving its terragrunt configuration. Files are
+// checked until one is found that determines the directory is a module, and
+// any errors parsing files are returned alongside the result.
+//
+// If local is true and no backend is found, then the directory is deemed to be
+// a root module using the local backend if its configuration contains a
+// provider block, or if it contains local state or a .terraform directory,
+// i.e. terraform has been run in the directory. Child modules rarely contain any
+// of these.
+func detectModule(workdir internal.Workdir, dir string, entries []fs.DirEntry, cache *detectionCache, local bool) (Options, bool, error) {
+	// Check terragrunt.hcl before any other files, because a terragrunt
+	// module may well contain .tf files with a backend too.
+	entries = slices.Clone(entries)
+	slices.SortStableFunc(entries, func(a, b fs.DirEntry) int {
+		switch {
+		case a.Name() == "terragrunt.hcl":
+			return -1
+		case b.Name() == "terragrunt.hcl":
+			return 1
+		default:
+			return 0
+		}
+	})
+	var (
+		errs []error
+		// whether the directory contains terraform configuration
+		configuration bool
+		// whether the directory shows signs of being a root module
+		root bool
+	)
+	module := func(backend string, terragrunt *TerragruntConfig) (Options, bool, error) {
+		// Strip workdir from module path
+		stripped, err := workdir.Rel(dir)
+		if err != nil {
+			return Options{}, false, err
+		}
+		return Options{Path: stripped, Backend: backend, Terragrunt: terragrunt}, true, errors.Join(errs...)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		switch {
+		case entry.Name() == ".terraform", entry.Name() == "terraform.tfstate", entry.Name() == "terraform.tfstate.d":
+			root = true
+		case entry.IsDir():
+		case entry.Name() == "terragrunt.hcl":
+			// The backend may be defined in an included file, so the result
+			// cannot be cached according to the modification time of this
+			// file alone.
+			backend, terragrunt, err := parseTerragruntConfig(path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			return module(backend, terragrunt)
+		case filepath.Ext(path) == ".tf":
+			configuration = true
+			info, err := entry.Info()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			detection, err := cache.detectFile(path, info)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if detection.Found {
+				return module(detection.Backend, nil)
+			}
+			if detection.Provider {
+				root = true
+			}
+		}
+	}
+	if local && configuration && root {
+		return module("local", nil)
+	}
+	return Options{}, false, errors.Join(errs...)
 }
 
 type terraform struct {
-	Terraform *terraformBlock `hcl:"terraform,block"`
-	Remain    hcl.Body        `hcl:",remain"`
+	Terraform *terraformBlock      `hcl:"terraform,block"`
+	Providers []*terraformProvider `hcl:"provider,block"`
+	Remain    hcl.Body             `hcl:",remain"`
 }
 
 type terraformBlock struct {
@@ -173,40 +308,46 @@ type terraformCloud struct {
 	Remain hcl.Body `hcl:",remain"`
 }
 
-// detectBackend parses the HCL file at the given path and detects whether it
-// found a backend configuration, together with the type of backend it found.
-func detectBackend(path string) (string, bool, error) {
+type terraformProvider struct {
+	Name   string   `hcl:"name,label"`
+	Remain hcl.Body `hcl:",remain"`
+}
+
+// fileDetection is the result of parsing a terraform file to detect whether
+// its directory is a root module.
+type fileDetection struct {
+	// Backend is the type of backend found.
+	Backend string `json:"backend"`
+	// Found is true if a backend or cloud block was found.
+	Found bool `json:"found"`
+	// Provider is true if a provider block was found.
+	Provider bool `json:"provider"`
+}
+
+// detectFile parses the HCL file at the given path and detects whether it
+// found a backend configuration, together with the type of backend it found,
+// and whether it found a provider configuration.
+func detectFile(path string) (fileDetection, error) {
 	f, err := hclparse.NewParser().ParseHCLFile(path)
 	if err != nil {
-		return "", false, err
+		return fileDetection{}, err
 	}
-	// Detect terraform backend
 	var terraform terraform
 	if diags := gohcl.DecodeBody(f.Body, nil, &terraform); diags != nil {
-		return "", false, diags
+		return fileDetection{}, diags
+	}
+	detection := fileDetection{
+		Provider: len(terraform.Providers) > 0,
 	}
 	if terraform.Terraform != nil {
 		if terraform.Terraform.Backend != nil {
-			return terraform.Terraform.Backend.Type, true, nil
+			detection.Backend = terraform.Terraform.Backend.Type
+			detection.Found = true
 		}
 		if terraform.Terraform.Cloud != nil {
-			return "cloud", true, nil
+			detection.Backend = "cloud"
+			detection.Found = true
 		}
 	}
-	// Detect terragrunt remote state configuration.
-	//
-	// Unless terragrunt.hcl directly contains a `remote_state` block then Pug
-	// doesn't have a way of determining the backend type (not unless it
-	// evaluates terragrunt's language and follows `find_in_parent` etc. to
-	// locate the effective remote_state, which is perhaps a future
-	// exercise...). If it doesn't contain such a block then the backend is
-	// simply an empty string.
-	var remoteStateBlock terragrunt
-	if diags := gohcl.DecodeBody(f.Body, nil, &remoteStateBlock); diags != nil {
-		return "", false, diags
-	}
-	if remoteStateBlock.RemoteState != nil {
-		return remoteStateBlock.RemoteState.Backend, true, nil
-	}
-	return "", false, nil
+	return detection, nil
 }
@@ -3,10 +3,12 @@ package module
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/leg100/pug/internal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -18,10 +20,12 @@ func TestNew(t *testing.T) {
 
 func TestFindModules(t *testing.T) {
 	workdir, _ := internal.NewWorkdir("./testdata/modules")
-	modules, errch := find(context.Background(), workdir)
+	modules, errch := find(context.Background(), workdir, newIgnorer(workdir, IgnoreOptions{}), newDetectionCache("", workdir.String()), false)
 
 	var got []Options
 	for opts := range modules {
+		// Terragrunt configuration is tested separately.
+		opts.Terragrunt = nil
 		got = append(got, opts)
 	}
 
@@ -40,3 +44,58 @@ func TestFindModules(t *testing.T) {
 	_, closed := <-errch
 	assert.False(t, closed)
 }
+
+func TestFindModules_Local(t *testing.T) {
+	workdir := internal.NewTestWorkdir(t)
+
+	write := func(path, content string) {
+		err := os.MkdirAll(filepath.Dir(workdir.Join(path)), 0o755)
+		require.NoError(t, err)
+		err = os.WriteFile(workdir.Join(path), []byte(content), 0o644)
+		require.NoError(t, err)
+	}
+	write("with_backend/main.tf", "terraform {\n  backend \"s3\" {}\n}\n")
+	write("with_provider/main.tf", "provider \"null\" {}\n")
+	write("with_state/main.tf", "resource \"null_resource\" \"foo\" {}\n")
+	write("with_state/terraform.tfstate", "{}")
+	write("with_dot_terraform/main.tf", "resource \"null_resource\" \"foo\" {}\n")
+	write("with_dot_terraform/.terraform/environment", "default")
+	write("library/variables.tf", "variable \"foo\" {}\n")
+	write("library/main.tf", "resource \"null_resource\" \"foo\" {}\n")
+	write("state_only/terraform.tfstate", "{}")
+
+	tests := []struct {
+		name  string
+		local bool
+		want  []Options
+	}{
+		{
+			name: "disabled",
+			want: []Options{
+				{Path: "with_backend", Backend: "s3"},
+			},
+		},
+		{
+			name:  "enabled",
+			local: true,
+			want: []Options{
+				{Path: "with_backend", Backend: "s3"},
+				{Path: "with_provider", Backend: "local"},
+				{Path: "with_state", Backend: "local"},
+				{Path: "with_dot_terraform", Backend: "local"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modules, errc := find(context.Background(), workdir, newIgnorer(workdir, IgnoreOptions{}), newDetectionCache("", workdir.String()), tt.local)
+
+			var got []Options
+			for opts := range modules {
+				got = append(got, opts)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+			assert.NoError(t, <-errc)
+		})
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
-	"slices"
+	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
@@ -22,6 +25,14 @@ type Service struct {
 	pluginCache bool
 	logger      logging.Interface
 	terragrunt  bool
+	ignore      IgnoreOptions
+	cache       *detectionCache
+	// discoverLocal enables the discovery of root modules without a backend
+	// configuration.
+	discoverLocal bool
+
+	// mu ensures only one reload of modules takes place at any one time.
+	mu sync.Mutex
 
 	*pubsub.Broker[*Module]
 }
@@ -32,6 +43,13 @@ type ServiceOptions struct {
 	PluginCache bool
 	Logger      logging.Interface
 	Terragrunt  bool
+	Ignore      IgnoreOptions
+	// DataDir is the directory in which to persist a cache of the results of
+	// searching for modules. If empty, the cache is not persisted.
+	DataDir string
+	// DiscoverLocal enables the discovery of root modules without a backend
+	// configuration, which use the local backend by default.
+	DiscoverLocal bool
 }
 
 type taskCreator interface {
@@ -56,6 +74,12 @@ func NewService(opts ServiceOptions) *Service {
 		Field:  "ModuleID",
 	})
 
+	cache := newDetectionCache(opts.DataDir, opts.Workdir.String())
+	if err := cache.load(); err != nil {
+		// Not fatal; modules are detected without the benefit of the cache.
+		opts.Logger.Error("loading module cache", "error", err)
+	}
+
 	return &Service{
 		table:       table,
 		Broker:      broker,
@@ -64,6 +88,10 @@ func NewService(opts ServiceOptions) *Service {
 		pluginCache: opts.PluginCache,
 		logger:      opts.Logger,
 		terragrunt:  opts.Terragrunt,
+		ignore:      opts.Ignore,
+		cache:       cache,
+
+		discoverLocal: opts.DiscoverLocal,
 	}
 }
 
@@ -73,8 +101,11 @@ func NewService(opts ServiceOptions) *Service {
 //
 // TODO: separate into Load and Reload
 func (s *Service) Reload() (added []string, removed []string, err error) {
-	ch, errc := find(context.TODO(), s.workdir)
-	var found []string
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, errc := find(context.TODO(), s.workdir, newIgnorer(s.workdir, s.ignore), s.cache, s.discoverLocal)
+	found := make(map[string]struct{})
 	for ch != nil || errc != nil {
 		select {
 		case opts, ok := <-ch:
@@ -82,7 +113,7 @@ func (s *Service) Reload() (added []string, removed []string, err error) {
 				ch = nil
 				break
 			}
-			found = append(found, opts.Path)
+			found[opts.Path] = struct{}{}
 			// handle found module
 			if mod, err := s.GetByPath(opts.Path); errors.Is(err, resource.ErrNotFound) {
 				// Not found, so add to pug
@@ -95,6 +126,7 @@ func (s *Service) Reload() (added []string, removed []string, err error) {
 				// Update in-place; the backend may have changed.
 				s.table.Update(mod.ID, func(existing *Module) error {
 					existing.Backend = opts.Backend
+					existing.Terragrunt = opts.Terragrunt
 					return nil
 				})
 			}
@@ -110,13 +142,17 @@ func (s *Service) Reload() (added []string, removed []string, err error) {
 	}
 	// Cleanup existing modules, removing those that are no longer to be found
 	for _, existing := range s.table.List() {
-		if !slices.Contains(found, existing.Path) {
+		if _, ok := found[existing.Path]; !ok {
 			s.table.Delete(existing.ID)
 			removed = append(removed, existing.Path)
 		}
 	}
 	s.logger.Info("reloaded modules", "added", added, "removed", removed)
 
+	if err := s.cache.save(); err != nil {
+		s.logger.Error("saving module cache", "error", err)
+	}
+
 	if s.terragrunt {
 		if err := s.loadTerragruntDependencies(); err != nil {
 			s.logger.Error("loading terragrunt dependencies: %w", err)
@@ -125,6 +161,91 @@ func (s *Service) Reload() (added []string, removed []string, err error) {
 	return
 }
 
+// reloadDirs incrementally reloads modules, only checking the given
+// directories rather than searching the entire working directory. Directories
+// that no longer exist result in the removal of any modules in or beneath the
+// directory. Each directory is an absolute path.
+func (s *Service) reloadDirs(dirs ...string) (added []string, removed []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ig := newIgnorer(s.workdir, s.ignore)
+	for _, dir := range dirs {
+		path, err := s.workdir.Rel(dir)
+		if err != nil {
+			s.logger.Error("reloading modules", "error", err, "dir", dir)
+			continue
+		}
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			// Directory has been removed, so remove any modules in or beneath
+			// directory.
+			for _, existing := range s.table.List() {
+				if existing.Path == path || strings.HasPrefix(existing.Path, path+string(filepath.Separator)) {
+					s.table.Delete(existing.ID)
+					removed = append(removed, existing.Path)
+				}
+			}
+			continue
+		}
+		var (
+			opts  Options
+			found bool
+		)
+		if !ig.ignored(path) && ig.included(path) {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				s.logger.Error("reloading modules", "error", err, "dir", dir)
+				continue
+			}
+			opts, found, err = detectModule(s.workdir, dir, entries, s.cache, s.discoverLocal)
+			if err != nil {
+				s.logger.Error("reloading modules", "error", err, "dir", dir)
+				if !found {
+					// Unable to determine whether the directory is a module,
+					// so leave any existing module as it is.
+					continue
+				}
+			}
+		}
+		existing, err := s.GetByPath(path)
+		switch {
+		case found && err != nil:
+			// Newly discovered module
+			mod := New(opts)
+			s.table.Add(mod.ID, mod)
+			added = append(added, opts.Path)
+		case found:
+			// Update in-place; the backend or terragrunt configuration may
+			// have changed.
+			if existing.Backend != opts.Backend || !reflect.DeepEqual(existing.Terragrunt, opts.Terragrunt) {
+				s.table.Update(existing.ID, func(existing *Module) error {
+					existing.Backend = opts.Backend
+					existing.Terragrunt = opts.Terragrunt
+					return nil
+				})
+			}
+		case err == nil:
+			// Directory is no longer a module
+			s.table.Delete(existing.ID)
+			removed = append(removed, existing.Path)
+		}
+	}
+	if err := s.cache.update(); err != nil {
+		s.logger.Error("saving module cache", "error", err)
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	s.logger.Info("reloaded modules", "added", added, "removed", removed)
+
+	if s.terragrunt {
+		if err := s.loadTerragruntDependencies(); err != nil {
+			s.logger.Error("loading terragrunt dependencies", "error", err)
+		}
+	}
+	return
+}
+
 func (s *Service) loadTerragruntDependencies() error {
 	task, err := s.tasks.Create(task.Spec{
 		Execution: task.Execution{
@@ -230,23 +351,6 @@ func (s *Service) Init(moduleID resource.ID, upgrade bool) (task.Spec, error) {
 	return spec, nil
 }
 
-func (s *Service) Format(moduleID resource.ID) (task.Spec, error) {
-	mod, err := s.table.Get(moduleID)
-	if err != nil {
-		return task.Spec{}, err
-	}
-	spec := task.Spec{
-		ModuleID: &mod.ID,
-		Path:     mod.Path,
-		Execution: task.Execution{
-			TerraformCommand: []string{"fmt"},
-		},
-		Immediate: true,
-		Short:     true,
-	}
-	return spec, nil
-}
-
 func (s *Service) Validate(moduleID resource.ID) (task.Spec, error) {
 	mod, err := s.table.Get(moduleID)
 	if err != nil {
@@ -257,9 +361,12 @@ func (s *Service) Validate(moduleID resource.ID) (task.Spec, error) {
 		Path:     mod.Path,
 		Execution: task.Execution{
 			TerraformCommand: []string{"validate"},
+			Args:             []string{"-json"},
 		},
-		Immediate: true,
-		Short:     true,
+		JSON:        true,
+		Diagnostics: true,
+		Immediate:   true,
+		Short:       true,
 	}
 	return spec, nil
 }
@@ -0,0 +1,311 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// TerragruntConfig is configuration parsed from a module's terragrunt.hcl file,
+// together with the configuration it includes.
+type TerragruntConfig struct {
+	// Includes are the paths of included configuration files, relative to the
+	// module directory.
+	Includes []string
+	// Inputs are the inputs passed to the module, keyed by name, with each
+	// value being its HCL expression as written in the configuration.
+	Inputs map[string]string
+	// Dependencies are the module's dependencies, each with a name and the path
+	// to the dependency relative to the module directory.
+	Dependencies []TerragruntDependency
+}
+
+// TerragruntDependency is a dependency on another terragrunt module.
+type TerragruntDependency struct {
+	// Name of the dependency block, or empty if it is listed in a
+	// dependencies block.
+	Name string
+	// Path to the dependency.
+	Path string
+}
+
+func (d TerragruntDependency) String() string {
+	if d.Name == "" {
+		return d.Path
+	}
+	return d.Name
+}
+
+// InputNames returns the names of the inputs in alphabetical order.
+func (c *TerragruntConfig) InputNames() []string {
+	names := make([]string, 0, len(c.Inputs))
+	for name := range c.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseTerragruntConfig parses the terragrunt configuration file at the given
+// path, resolving any include blocks, and returns the effective backend type
+// along with the parsed configuration.
+//
+// Terragrunt is not invoked; instead a subset of terragrunt's functions are
+// evaluated locally, e.g. find_in_parent_folders(), which is sufficient for
+// resolving the path of an included file in most cases. If the backend cannot
+// be determined then an empty string is returned.
+func parseTerragruntConfig(path string) (string, *TerragruntConfig, error) {
+	dir := filepath.Dir(path)
+	child, err := parseTerragruntFile(path, dir, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	cfg := &TerragruntConfig{
+		Inputs:       make(map[string]string),
+		Dependencies: child.dependencies,
+	}
+	backend := child.backend
+	// Terragrunt merges included configuration into the child configuration,
+	// with the child taking precedence.
+	for _, include := range child.includes {
+		rel, err := filepath.Rel(dir, include)
+		if err != nil {
+			rel = include
+		}
+		cfg.Includes = append(cfg.Includes, rel)
+
+		parent, err := parseTerragruntFile(include, dir, filepath.Dir(include))
+		if err != nil {
+			// Skip includes that cannot be parsed. Terragrunt itself will
+			// report an error when run.
+			continue
+		}
+		if backend == "" {
+			backend = parent.backend
+		}
+		for k, v := range parent.inputs {
+			cfg.Inputs[k] = v
+		}
+		cfg.Dependencies = append(cfg.Dependencies, parent.dependencies...)
+	}
+	for k, v := range child.inputs {
+		cfg.Inputs[k] = v
+	}
+	return backend, cfg, nil
+}
+
+// terragruntFile is the result of parsing an individual terragrunt
+// configuration file.
+type terragruntFile struct {
+	backend      string
+	includes     []string
+	inputs       map[string]string
+	dependencies []TerragruntDependency
+}
+
+// parseTerragruntFile parses a terragrunt configuration file. The file is
+// evaluated in the context of the terragrunt module in terragruntDir. If the
+// file is included by the module then parentDir is the directory containing
+// the file, otherwise it is the same as terragruntDir.
+func parseTerragruntFile(path, terragruntDir, parentDir string) (*terragruntFile, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.New("unsupported terragrunt configuration syntax")
+	}
+	ctx := terragruntEvalContext(terragruntDir, parentDir)
+	// Render an expression as it is written in the configuration.
+	source := func(expr hclsyntax.Expression) string {
+		rng := expr.Range()
+		return string(rng.SliceBytes(src))
+	}
+	// Evaluate an expression to a string, returning false if it cannot be
+	// evaluated.
+	evalString := func(expr hclsyntax.Expression) (string, bool) {
+		v, diags := expr.Value(ctx)
+		if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+			return "", false
+		}
+		return v.AsString(), true
+	}
+
+	result := &terragruntFile{inputs: make(map[string]string)}
+	if attr, ok := body.Attributes["inputs"]; ok {
+		if obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr); ok {
+			for _, item := range obj.Items {
+				key := hcl.ExprAsKeyword(item.KeyExpr)
+				if key == "" {
+					var ok bool
+					if key, ok = evalString(item.KeyExpr); !ok {
+						continue
+					}
+				}
+				result.inputs[key] = source(item.ValueExpr)
+			}
+		}
+	}
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "remote_state":
+			if attr, ok := block.Body.Attributes["backend"]; ok {
+				result.backend, _ = evalString(attr.Expr)
+			}
+		case "include":
+			attr, ok := block.Body.Attributes["path"]
+			if !ok {
+				continue
+			}
+			include, ok := evalString(attr.Expr)
+			if !ok {
+				continue
+			}
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(terragruntDir, include)
+			}
+			result.includes = append(result.includes, include)
+		case "dependency":
+			if len(block.Labels) != 1 {
+				continue
+			}
+			dep := TerragruntDependency{Name: block.Labels[0]}
+			if attr, ok := block.Body.Attributes["config_path"]; ok {
+				dep.Path, _ = evalString(attr.Expr)
+			}
+			result.dependencies = append(result.dependencies, dep)
+		case "dependencies":
+			attr, ok := block.Body.Attributes["paths"]
+			if !ok {
+				continue
+			}
+			v, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() || !v.IsWhollyKnown() || !v.CanIterateElements() {
+				continue
+			}
+			for it := v.ElementIterator(); it.Next(); {
+				_, elem := it.Element()
+				if elem.Type() != cty.String || elem.IsNull() {
+					continue
+				}
+				path := elem.AsString()
+				// A path may be listed in both a dependency block and a
+				// dependencies block.
+				if !slices.ContainsFunc(result.dependencies, func(dep TerragruntDependency) bool {
+					return dep.Path == path
+				}) {
+					result.dependencies = append(result.dependencies, TerragruntDependency{Path: path})
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// terragruntEvalContext constructs an evaluation context with a subset of
+// terragrunt's built-in functions.
+func terragruntEvalContext(terragruntDir, parentDir string) *hcl.EvalContext {
+	stringFunc := func(fn func() (string, error)) function.Function {
+		return function.New(&function.Spec{
+			Type: function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				s, err := fn()
+				if err != nil {
+					return cty.NilVal, err
+				}
+				return cty.StringVal(s), nil
+			},
+		})
+	}
+	return &hcl.EvalContext{
+		Functions: map[string]function.Function{
+			"find_in_parent_folders": function.New(&function.Spec{
+				VarParam: &function.Parameter{Name: "args", Type: cty.String},
+				Type:     function.StaticReturnType(cty.String),
+				Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+					name := "terragrunt.hcl"
+					if len(args) > 0 {
+						name = args[0].AsString()
+					}
+					for dir := filepath.Dir(terragruntDir); ; dir = filepath.Dir(dir) {
+						path := filepath.Join(dir, name)
+						if _, err := os.Stat(path); err == nil {
+							return cty.StringVal(path), nil
+						}
+						if dir == filepath.Dir(dir) {
+							break
+						}
+					}
+					if len(args) > 1 {
+						// Return fallback
+						return args[1], nil
+					}
+					return cty.NilVal, fmt.Errorf("could not find %s in any parent folder", name)
+				},
+			}),
+			"get_terragrunt_dir": stringFunc(func() (string, error) {
+				return terragruntDir, nil
+			}),
+			"get_parent_terragrunt_dir": stringFunc(func() (string, error) {
+				return parentDir, nil
+			}),
+			"path_relative_to_include": stringFunc(func() (string, error) {
+				return filepath.Rel(parentDir, terragruntDir)
+			}),
+			"path_relative_from_include": stringFunc(func() (string, error) {
+				return filepath.Rel(terragruntDir, parentDir)
+			}),
+			"get_env": function.New(&function.Spec{
+				Params:   []function.Parameter{{Name: "name", Type: cty.String}},
+				VarParam: &function.Parameter{Name: "default", Type: cty.String},
+				Type:     function.StaticReturnType(cty.String),
+				Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+					if v, ok := os.LookupEnv(args[0].AsString()); ok {
+						return cty.StringVal(v), nil
+					}
+					if len(args) > 1 {
+						return args[1], nil
+					}
+					return cty.StringVal(""), nil
+				},
+			}),
+		},
+	}
+}
+
+// Summary summarises the configuration in a single line.
+func (c *TerragruntConfig) Summary() string {
+	var parts []string
+	if len(c.Includes) > 0 {
+		parts = append(parts, "includes: "+strings.Join(c.Includes, ", "))
+	}
+	if len(c.Dependencies) > 0 {
+		deps := make([]string, len(c.Dependencies))
+		for i, dep := range c.Dependencies {
+			deps[i] = dep.String()
+		}
+		parts = append(parts, "dependencies: "+strings.Join(deps, ", "))
+	}
+	if len(c.Inputs) > 0 {
+		inputs := make([]string, 0, len(c.Inputs))
+		for _, name := range c.InputNames() {
+			inputs = append(inputs, fmt.Sprintf("%s = %s", name, c.Inputs[name]))
+		}
+		parts = append(parts, "inputs: "+strings.Join(inputs, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
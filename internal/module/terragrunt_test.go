@@ -0,0 +1,84 @@
+package module
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTerragruntConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		wantBackend string
+		want        *TerragruntConfig
+	}{
+		{
+			name:        "remote state",
+			path:        "./testdata/modules/terragrunt_with_local/terragrunt.hcl",
+			wantBackend: "local",
+			want: &TerragruntConfig{
+				Inputs: map[string]string{},
+			},
+		},
+		{
+			name:        "include with missing parent",
+			path:        "./testdata/modules/terragrunt_without_backend/terragrunt.hcl",
+			wantBackend: "",
+			want: &TerragruntConfig{
+				Inputs: map[string]string{},
+			},
+		},
+		{
+			name:        "include default parent",
+			path:        "./testdata/terragrunt/root/vpc/terragrunt.hcl",
+			wantBackend: "local",
+			want: &TerragruntConfig{
+				Includes: []string{filepath.Join("..", "..", "terragrunt.hcl")},
+				Inputs:   map[string]string{},
+			},
+		},
+		{
+			name:        "include named parent with inputs",
+			path:        "./testdata/terragrunt_includes/live/prod/vpc/terragrunt.hcl",
+			wantBackend: "s3",
+			want: &TerragruntConfig{
+				Includes: []string{filepath.Join("..", "..", "..", "root.hcl")},
+				Inputs: map[string]string{
+					"cidr":   `"10.0.0.0/16"`,
+					"region": `"us-east-1"`,
+				},
+			},
+		},
+		{
+			name:        "dependencies",
+			path:        "./testdata/terragrunt_includes/live/prod/app/terragrunt.hcl",
+			wantBackend: "s3",
+			want: &TerragruntConfig{
+				Includes: []string{filepath.Join("..", "..", "..", "root.hcl")},
+				Inputs: map[string]string{
+					"region": `"eu-west-2"`,
+					"vpc_id": "dependency.vpc.outputs.vpc_id",
+				},
+				Dependencies: []TerragruntDependency{
+					{Name: "vpc", Path: "../vpc"},
+					{Path: "../db"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := filepath.Abs(tt.path)
+			require.NoError(t, err)
+
+			backend, got, err := parseTerragruntConfig(path)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantBackend, backend)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+}
+
+dependencies {
+  paths = ["../vpc", "../db"]
+}
+
+inputs = {
+  vpc_id = dependency.vpc.outputs.vpc_id
+}
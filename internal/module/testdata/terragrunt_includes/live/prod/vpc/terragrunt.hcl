@@ -0,0 +1,8 @@
+include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+
+inputs = {
+  cidr   = "10.0.0.0/16"
+  region = "us-east-1"
+}
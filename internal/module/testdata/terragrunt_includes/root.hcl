@@ -0,0 +1,11 @@
+remote_state {
+  backend = "s3"
+  config = {
+    bucket = "tfstate"
+    key    = "${path_relative_to_include()}/terraform.tfstate"
+  }
+}
+
+inputs = {
+  region = "eu-west-2"
+}
@@ -0,0 +1,354 @@
+package module
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/leg100/pug/internal/resource"
+	"golang.org/x/exp/maps"
+)
+
+// debounceInterval is how long the watcher waits for filesystem events to
+// settle before reloading modules and workspaces.
+const debounceInterval = 500 * time.Millisecond
+
+// WorkspaceChange notifies that a module's workspaces may have changed on disk.
+type WorkspaceChange struct {
+	ModuleID resource.ID
+	// CurrentOnly is true if only the module's current workspace may have
+	// changed, i.e. its .terraform/environment file was altered, and false if
+	// workspaces may have been added or removed, i.e. a .tfvars file was added
+	// or removed.
+	CurrentOnly bool
+}
+
+// StateChange notifies that the local state of a module's workspace has
+// changed on disk.
+type StateChange struct {
+	ModuleID resource.ID
+	// Workspace is the name of the workspace.
+	Workspace string
+}
+
+// watcher watches the working directory for changes to modules and their
+// workspaces.
+type watcher struct {
+	*Service
+
+	fsw     *fsnotify.Watcher
+	changes chan WorkspaceChange
+	states  chan StateChange
+	// ignore skips watching ignored directories.
+	ignore *ignorer
+}
+
+// Watch watches the working directory for filesystem changes, adding and
+// removing modules as their configuration files are added and removed. Changes
+// that may affect a module's workspaces are sent on the first returned channel,
+// and changes to the local state of modules using the local backend are sent on
+// the second returned channel. Both channels are closed once the context is
+// canceled.
+func (s *Service) Watch(ctx context.Context) (<-chan WorkspaceChange, <-chan StateChange, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+	w := &watcher{
+		Service: s,
+		fsw:     fsw,
+		changes: make(chan WorkspaceChange),
+		states:  make(chan StateChange),
+		ignore:  newIgnorer(s.workdir, s.ignore),
+	}
+	if err := w.addRecursive(s.workdir.String()); err != nil {
+		fsw.Close()
+		return nil, nil, err
+	}
+	go w.run(ctx)
+	return w.changes, w.states, nil
+}
+
+// pending records changes awaiting processing once events have settled.
+type pending struct {
+	// directories in which modules may have been added or removed.
+	dirs map[string]struct{}
+	// directories of modules whose workspaces may have changed. The value is
+	// true if only the current workspace may have changed.
+	workspaceDirs map[string]bool
+	// local state files that have changed.
+	states map[localState]struct{}
+	// reloadAll is true if an ignore file has changed, in which case all
+	// modules are reloaded.
+	reloadAll bool
+}
+
+// addState records a change to the local state file at the given path.
+func (p *pending) addState(path string) {
+	parent := filepath.Dir(path)
+	// Local state for the default workspace is found in the module directory,
+	// whereas local state for other workspaces is found in
+	// terraform.tfstate.d/<workspace>.
+	if dir := filepath.Dir(parent); filepath.Base(dir) == "terraform.tfstate.d" {
+		p.states[localState{dir: filepath.Dir(dir), workspace: filepath.Base(parent)}] = struct{}{}
+	} else {
+		p.states[localState{dir: parent, workspace: "default"}] = struct{}{}
+		// With local module discovery enabled, the presence of local state may
+		// render the directory a module.
+		p.dirs[parent] = struct{}{}
+	}
+}
+
+// localState identifies the local state file for a workspace.
+type localState struct {
+	// directory of module
+	dir string
+	// name of workspace
+	workspace string
+}
+
+func (w *watcher) run(ctx context.Context) {
+	defer close(w.changes)
+	defer close(w.states)
+	defer w.fsw.Close()
+
+	var (
+		p = pending{
+			dirs:          make(map[string]struct{}),
+			workspaceDirs: make(map[string]bool),
+			states:        make(map[localState]struct{}),
+		}
+		// timer fires once events have settled.
+		timer = time.NewTimer(debounceInterval)
+	)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Error("watching working directory", "error", err)
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if w.ignore.isIgnoreFile(filepath.Base(event.Name)) {
+				p.reloadAll = true
+			} else {
+				w.handleEvent(event, &p)
+			}
+			timer.Reset(debounceInterval)
+		case <-timer.C:
+			if p.reloadAll {
+				// Directories previously ignored may no longer be ignored, so
+				// ensure they are watched.
+				if err := w.addRecursive(w.workdir.String()); err != nil {
+					w.logger.Error("watching working directory", "error", err)
+				}
+				if _, _, err := w.Reload(); err != nil {
+					w.logger.Error("reloading modules", "error", err)
+				}
+				p.reloadAll = false
+				clear(p.dirs)
+			} else if len(p.dirs) > 0 {
+				w.reloadDirs(maps.Keys(p.dirs)...)
+				clear(p.dirs)
+			}
+			for dir, currentOnly := range p.workspaceDirs {
+				path, err := w.workdir.Rel(dir)
+				if err != nil {
+					continue
+				}
+				mod, err := w.GetByPath(path)
+				if err != nil {
+					// Not a module (or no longer a module).
+					continue
+				}
+				select {
+				case w.changes <- WorkspaceChange{ModuleID: mod.ID, CurrentOnly: currentOnly}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			clear(p.workspaceDirs)
+			for state := range p.states {
+				path, err := w.workdir.Rel(state.dir)
+				if err != nil {
+					continue
+				}
+				mod, err := w.GetByPath(path)
+				if err != nil || mod.Backend != "local" {
+					continue
+				}
+				select {
+				case w.states <- StateChange{ModuleID: mod.ID, Workspace: state.workspace}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			clear(p.states)
+		}
+	}
+}
+
+// handleEvent classifies a filesystem event, recording which directories
+// require reloading.
+func (w *watcher) handleEvent(event fsnotify.Event, p *pending) {
+	var (
+		path   = event.Name
+		name   = filepath.Base(path)
+		parent = filepath.Dir(path)
+	)
+	if name == "terraform.tfstate" {
+		p.addState(path)
+		return
+	}
+	if filepath.Base(parent) == ".terraform" {
+		// Only the environment file within a .terraform directory is of
+		// interest, which records the current workspace.
+		if name == "environment" {
+			module := filepath.Dir(parent)
+			if _, ok := p.workspaceDirs[module]; !ok {
+				p.workspaceDirs[module] = true
+			}
+		}
+		return
+	}
+	if event.Has(fsnotify.Create) {
+		if isDir(path) {
+			if name == ".terraform" {
+				// Watch the .terraform directory itself, but not its
+				// descendents.
+				if err := w.fsw.Add(path); err != nil {
+					w.logger.Error("watching directory", "error", err, "path", path)
+				}
+				// The environment file may have been written before the
+				// directory was watched.
+				if _, err := os.Stat(filepath.Join(path, "environment")); err == nil {
+					if _, ok := p.workspaceDirs[parent]; !ok {
+						p.workspaceDirs[parent] = true
+					}
+				}
+				// With local module discovery enabled, the presence of a
+				// .terraform directory may render the directory a module.
+				p.dirs[parent] = struct{}{}
+				return
+			}
+			// A new directory may contain modules, possibly nested several
+			// levels down, e.g. if a directory has been moved into the working
+			// directory.
+			if err := w.addRecursive(path); err != nil {
+				w.logger.Error("watching directory", "error", err, "path", path)
+			}
+			_ = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				if d.Name() == "terraform.tfstate" {
+					// The state file may have been written before its
+					// directory was watched.
+					p.addState(path)
+				}
+				if d.IsDir() {
+					if skipDir(d.Name()) {
+						return filepath.SkipDir
+					}
+					p.dirs[path] = struct{}{}
+				}
+				return nil
+			})
+			return
+		}
+	}
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		// The removed path may have been a directory containing modules. It's no
+		// longer possible to determine whether it was a directory, so if there
+		// are any modules in or beneath the path then reload the path.
+		if rel, err := w.workdir.Rel(path); err == nil {
+			for _, mod := range w.table.List() {
+				if mod.Path == rel || strings.HasPrefix(mod.Path, rel+string(filepath.Separator)) {
+					p.dirs[path] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+	if filepath.Ext(name) == ".hcl" {
+		// The file may be included by terragrunt modules, in which case they
+		// need reloading.
+		for _, mod := range w.table.List() {
+			if mod.Terragrunt == nil {
+				continue
+			}
+			modDir := w.workdir.Join(mod.Path)
+			for _, include := range mod.Terragrunt.Includes {
+				if filepath.Join(modDir, include) == path {
+					p.dirs[modDir] = struct{}{}
+				}
+			}
+		}
+	}
+	switch {
+	case name == "terragrunt.hcl", filepath.Ext(name) == ".tf":
+		p.dirs[parent] = struct{}{}
+	case filepath.Ext(name) == ".tfvars":
+		if event.Has(fsnotify.Create) || event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+			p.workspaceDirs[parent] = false
+		}
+	}
+}
+
+// addRecursive watches the directory at the given path along with all its
+// descendent directories, skipping those that never contain modules or which
+// are ignored.
+func (w *watcher) addRecursive(root string) error {
+	// Use a fresh ignorer each time, because ignore files may have changed.
+	w.ignore = newIgnorer(w.workdir, w.Service.ignore)
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".terraform" {
+			// Watch .terraform for changes to the current workspace, but don't
+			// descend into it.
+			if err := w.fsw.Add(path); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		if skipDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if rel, err := w.workdir.Rel(path); err == nil && w.ignore.skip(rel) {
+			return filepath.SkipDir
+		}
+		return w.fsw.Add(path)
+	})
+}
+
+// skipDir determines whether a directory with the given name should be skipped
+// when watching for modules.
+func skipDir(name string) bool {
+	switch name {
+	case ".terraform", ".terragrunt-cache", ".git":
+		return true
+	default:
+		return false
+	}
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
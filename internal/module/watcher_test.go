@@ -0,0 +1,130 @@
+package module
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	workdir := internal.NewTestWorkdir(t)
+	svc := NewService(ServiceOptions{
+		Workdir: workdir,
+		Logger:  logging.Discard,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	changes, states, err := svc.Watch(ctx)
+	require.NoError(t, err)
+
+	// Add module with a backend, nested within a new directory
+	modPath := filepath.Join("a", "b")
+	err = os.MkdirAll(workdir.Join(modPath), 0o755)
+	require.NoError(t, err)
+	backend := []byte(`
+terraform {
+  backend "local" {}
+}
+`)
+	err = os.WriteFile(workdir.Join(modPath, "main.tf"), backend, 0o644)
+	require.NoError(t, err)
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		mod, err := svc.GetByPath(modPath)
+		if assert.NoError(c, err) {
+			assert.Equal(c, "local", mod.Backend)
+		}
+	}, 5*time.Second, 100*time.Millisecond)
+
+	mod, err := svc.GetByPath(modPath)
+	require.NoError(t, err)
+
+	// Change the current workspace
+	err = os.MkdirAll(workdir.Join(modPath, ".terraform"), 0o755)
+	require.NoError(t, err)
+	err = os.WriteFile(workdir.Join(modPath, ".terraform", "environment"), []byte("dev"), 0o644)
+	require.NoError(t, err)
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, WorkspaceChange{ModuleID: mod.ID, CurrentOnly: true}, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for workspace change")
+	}
+
+	// Add a workspace variables file
+	err = os.WriteFile(workdir.Join(modPath, "dev.tfvars"), nil, 0o644)
+	require.NoError(t, err)
+
+	select {
+	case got := <-changes:
+		assert.Equal(t, WorkspaceChange{ModuleID: mod.ID, CurrentOnly: false}, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for workspace change")
+	}
+
+	// Write local state for the default workspace
+	err = os.WriteFile(workdir.Join(modPath, "terraform.tfstate"), []byte("{}"), 0o644)
+	require.NoError(t, err)
+
+	select {
+	case got := <-states:
+		assert.Equal(t, StateChange{ModuleID: mod.ID, Workspace: "default"}, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for state change")
+	}
+
+	// Write local state for another workspace
+	err = os.MkdirAll(workdir.Join(modPath, "terraform.tfstate.d", "dev"), 0o755)
+	require.NoError(t, err)
+	err = os.WriteFile(workdir.Join(modPath, "terraform.tfstate.d", "dev", "terraform.tfstate"), []byte("{}"), 0o644)
+	require.NoError(t, err)
+
+	select {
+	case got := <-states:
+		assert.Equal(t, StateChange{ModuleID: mod.ID, Workspace: "dev"}, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for state change")
+	}
+
+	// Remove the parent directory of the module
+	err = os.RemoveAll(workdir.Join("a"))
+	require.NoError(t, err)
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Len(c, svc.List(), 0)
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestService_reloadDirs_ParseError(t *testing.T) {
+	workdir := internal.NewTestWorkdir(t)
+	dataDir := t.TempDir()
+	svc := NewService(ServiceOptions{
+		Workdir: workdir,
+		DataDir: dataDir,
+		Logger:  logging.Discard,
+	})
+
+	// Module with a backend alongside a file that cannot be parsed.
+	dir := workdir.Join("a")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	backend := []byte("terraform {\n  backend \"local\" {}\n}\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), backend, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.tf"), []byte("resource {"), 0o644))
+
+	added, _ := svc.reloadDirs(dir)
+	assert.Equal(t, []string{"a"}, added)
+
+	// The detection of the module's backend is persisted to the cache.
+	cache := newDetectionCache(dataDir, workdir.String())
+	require.NoError(t, cache.load())
+	assert.Contains(t, cache.entries, filepath.Join(dir, "main.tf"))
+}
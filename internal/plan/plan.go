@@ -1,17 +1,22 @@
 package plan
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/pubsub"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/state"
 	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
 )
 
 type plan struct {
@@ -24,6 +29,12 @@ type plan struct {
 	ArtefactsPath string
 	Destroy       bool
 	TargetAddrs   []state.ResourceAddress
+	// PolicyResults are the results of evaluating policies against the plan's
+	// changes, and are only set once the plan task has finished.
+	PolicyResults []PolicyResult
+	// PriorState is the version of the workspace's state against which the
+	// plan was created. Nil if unknown.
+	PriorState *StateVersion
 
 	targetArgs         []string
 	terragrunt         bool
@@ -31,6 +42,11 @@ type plan struct {
 	varsFileArg        *string
 	envs               []string
 	moduleDependencies []resource.ID
+	// policies are those policies that apply to the plan's workspace.
+	policies []Policy
+	// applyAfter is true if the plan is to be applied as soon as it is
+	// created, without review.
+	applyAfter bool
 
 	// taskID is the ID of the plan task, and is only set once the task is
 	// created.
@@ -42,6 +58,10 @@ type CreateOptions struct {
 	TargetAddrs []state.ResourceAddress
 	// Destroy creates a plan to destroy all resources.
 	Destroy bool
+	// OverridePolicies permits auto-applying a workspace to which policies
+	// apply. An auto-apply cannot be checked against policies, so it is
+	// otherwise refused.
+	OverridePolicies bool
 	// planFile is true if a plan file is first created with `terraform plan
 	// -out plan.file`.
 	planFile bool
@@ -54,6 +74,7 @@ type factory struct {
 	workspaces workspaceGetter
 	broker     *pubsub.Broker[*plan]
 	terragrunt bool
+	policies   []Policy
 }
 
 func (f *factory) newPlan(workspaceID resource.ID, opts CreateOptions) (*plan, error) {
@@ -77,6 +98,7 @@ func (f *factory) newPlan(workspaceID resource.ID, opts CreateOptions) (*plan, e
 		envs:               []string{ws.TerraformEnv()},
 		moduleDependencies: mod.Dependencies(),
 	}
+	plan.policies = f.policiesFor(ws)
 	if opts.planFile {
 		plan.ArtefactsPath = filepath.Join(f.dataDir, fmt.Sprintf("%d", plan.Serial))
 		if err := os.MkdirAll(plan.ArtefactsPath, 0o755); err != nil {
@@ -93,6 +115,17 @@ func (f *factory) newPlan(workspaceID resource.ID, opts CreateOptions) (*plan, e
 	return plan, nil
 }
 
+// policiesFor returns the policies that apply to the workspace.
+func (f *factory) policiesFor(ws *workspace.Workspace) []Policy {
+	var policies []Policy
+	for _, policy := range f.policies {
+		if policy.appliesTo(ws) {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
 func (r *plan) planPath() string {
 	return filepath.Join(r.ArtefactsPath, "plan")
 }
@@ -115,6 +148,7 @@ func (r *plan) planTaskSpec() task.Spec {
 		},
 		// TODO: explain why plan is blocking (?)
 		Blocking:    true,
+		Diagnostics: true,
 		Description: "plan",
 		AfterCreate: func(t *task.Task) {
 			r.taskID = &t.ID
@@ -124,11 +158,36 @@ func (r *plan) planTaskSpec() task.Spec {
 			if err != nil {
 				return nil, err
 			}
+			var show []byte
+			if len(r.policies) > 0 {
+				out, show = splitShowOutput(out)
+			}
 			changes, report, err := parsePlanReport(string(out))
 			if err != nil {
 				return nil, err
 			}
 			r.HasChanges = changes
+			if changes {
+				// Record the version of the state the plan was created
+				// against, to detect a stale plan before it is applied. If the
+				// version cannot be read from the plan file then detection is
+				// left to terraform upon applying the plan.
+				if version, err := readPlanState(r.planPath()); err == nil {
+					r.PriorState = &version
+				}
+			}
+			if changes && len(r.policies) > 0 {
+				var pf planFile
+				if err := json.Unmarshal(show, &pf); err != nil {
+					return nil, fmt.Errorf("parsing plan file: %w", err)
+				}
+				r.PolicyResults = evaluate(r.policies, &pf)
+			}
+			// The plan is to be applied without review, so fail the plan if it
+			// violates policies, which cancels the apply.
+			if violations := Violations(r.PolicyResults); r.applyAfter && len(violations) > 0 {
+				return report, fmt.Errorf("%w: %s", ErrPolicyViolation, strings.Join(violations, ", "))
+			}
 			return report, nil
 		},
 	}
@@ -139,18 +198,66 @@ func (r *plan) planTaskSpec() task.Spec {
 		spec.Execution.Args = append(spec.Execution.Args, "-destroy")
 		spec.Description += " (destroy)"
 	}
+	if len(r.policies) > 0 {
+		// Policies are evaluated against the contents of the plan file, i.e.
+		// `terraform show -json plan.file`, which is run as part of the task
+		// so that should it fail the user can see why.
+		spec.AdditionalExecution = &task.Execution{
+			TerraformCommand: []string{"show"},
+			Args:             []string{"-json", r.planPath()},
+		}
+	}
 	return spec
 }
 
+// splitShowOutput splits the output of a plan task into the output of the plan
+// and the output of `terraform show -json`, which is the last line.
+func splitShowOutput(out []byte) (plan, show []byte) {
+	out = bytes.TrimRight(out, "\n")
+	i := bytes.LastIndexByte(out, '\n')
+	return out[:i+1], out[i+1:]
+}
+
 const (
 	PlanTask  task.Identifier = "plan"
 	ApplyTask task.Identifier = "apply"
 )
 
+// AutoApplies returns true if the spec is for a task that auto-applies a
+// workspace, i.e. one that applies changes without a plan file.
+func AutoApplies(spec task.Spec) bool {
+	return spec.Identifier == ApplyTask && slices.Contains(spec.Execution.Args, "-auto-approve")
+}
+
+// applyPlanTaskSpec returns a spec for a task applying the plan file created
+// by the plan task. It is refused if the plan is stale, or if the plan violates
+// policies, unless they're overridden.
+func (r *plan) applyPlanTaskSpec(pull func(*plan) (StateVersion, error), overridePolicies bool) (task.Spec, error) {
+	if err := r.checkState(pull); err != nil {
+		return task.Spec{}, err
+	}
+	return r.checkedApplyPlanTaskSpec(overridePolicies)
+}
+
+// checkedApplyPlanTaskSpec is the same as applyPlanTaskSpec but skips checking
+// whether the plan is stale.
+func (r *plan) checkedApplyPlanTaskSpec(overridePolicies bool) (task.Spec, error) {
+	if violations := Violations(r.PolicyResults); len(violations) > 0 && !overridePolicies {
+		return task.Spec{}, fmt.Errorf("%w: %s", ErrPolicyViolation, strings.Join(violations, ", "))
+	}
+	return r.applyTaskSpec()
+}
+
 func (r *plan) applyTaskSpec() (task.Spec, error) {
 	if r.planFile && !r.HasChanges {
 		return task.Spec{}, errors.New("plan does not have any changes to apply")
 	}
+	return r.newApplyTaskSpec(), nil
+}
+
+// newApplyTaskSpec returns a spec for a task applying the plan, either the plan
+// file, or, if there is no plan file, an auto-apply.
+func (r *plan) newApplyTaskSpec() task.Spec {
 	spec := task.Spec{
 		Identifier:  ApplyTask,
 		ModuleID:    &r.ModuleID,
@@ -162,6 +269,7 @@ func (r *plan) applyTaskSpec() (task.Spec, error) {
 		},
 		Env:         r.envs,
 		Blocking:    true,
+		Diagnostics: true,
 		Description: "apply",
 		BeforeExited: func(t *task.Task) (task.Summary, error) {
 			out, err := io.ReadAll(t.NewReader(false))
@@ -201,5 +309,5 @@ func (r *plan) applyTaskSpec() (task.Spec, error) {
 		}
 		spec.Description += " (destroy)"
 	}
-	return spec, nil
+	return spec
 }
@@ -1,9 +1,14 @@
 package plan
 
+import "slices"
+
 const (
 	CreateAction ChangeAction = "create"
 	UpdateAction ChangeAction = "update"
 	DeleteAction ChangeAction = "delete"
+	// ReplaceAction is not an action found in a plan file but the combination
+	// of a delete and a create action.
+	ReplaceAction ChangeAction = "replace"
 )
 
 type (
@@ -15,7 +20,9 @@ type (
 
 	// ResourceChange represents a proposed change to a resource in a plan file
 	ResourceChange struct {
-		Change Change
+		Address string `json:"address"`
+		Type    string `json:"type"`
+		Change  Change
 	}
 
 	// Change represents the type of change being made
@@ -26,6 +33,24 @@ type (
 	ChangeAction string
 )
 
+// action returns the action of the change, where a change that both deletes
+// and creates a resource is a replacement. False is returned if the change
+// neither creates, updates nor deletes a resource, e.g. a no-op or a read.
+func (c Change) action() (ChangeAction, bool) {
+	if slices.Contains(c.Actions, DeleteAction) && slices.Contains(c.Actions, CreateAction) {
+		return ReplaceAction, true
+	}
+	if len(c.Actions) != 1 {
+		return "", false
+	}
+	switch c.Actions[0] {
+	case CreateAction, UpdateAction, DeleteAction:
+		return c.Actions[0], true
+	default:
+		return "", false
+	}
+}
+
 //lint:ignore U1000 intend to use shortly
 func (pf *planFile) resourceChanges() (resource Report) {
 	for _, rc := range pf.ResourceChanges {
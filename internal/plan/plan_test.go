@@ -1,12 +1,17 @@
 package plan
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/logging"
 	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/pubsub"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/testutils"
 	"github.com/leg100/pug/internal/workspace"
@@ -72,3 +77,88 @@ type fakeWorkspaceGetter struct {
 func (f *fakeWorkspaceGetter) Get(resource.ID) (*workspace.Workspace, error) {
 	return f.ws, nil
 }
+
+func TestService_Apply_ForbidAutoApply(t *testing.T) {
+	f, _, ws := setupTest(t)
+	ws.Protected = true
+	ws.ForbidAutoApply = true
+	svc := &Service{workspaces: f.workspaces, factory: f}
+
+	_, err := svc.Apply(ws.ID, CreateOptions{})
+	assert.ErrorContains(t, err, "auto-apply is forbidden")
+}
+
+func TestService_Apply_Policies(t *testing.T) {
+	f, _, ws := setupTest(t)
+	f.policies = []Policy{
+		{Name: "no-deletions", Actions: []ChangeAction{DeleteAction}},
+		{Name: "prod-only", Workspaces: []string{"**:prod"}},
+	}
+	svc := &Service{workspaces: f.workspaces, factory: f}
+
+	_, err := svc.Apply(ws.ID, CreateOptions{})
+	assert.ErrorIs(t, err, ErrPoliciesUnchecked)
+	assert.ErrorContains(t, err, "no-deletions")
+	assert.NotContains(t, err.Error(), "prod-only")
+
+	spec, err := svc.Apply(ws.ID, CreateOptions{OverridePolicies: true})
+	require.NoError(t, err)
+	assert.True(t, AutoApplies(spec))
+}
+
+func TestService_PlanAndApply(t *testing.T) {
+	f, _, ws := setupTest(t)
+	svc := &Service{
+		table:      resource.NewTable(pubsub.NewBroker[*plan](logging.Discard)),
+		workspaces: f.workspaces,
+		factory:    f,
+	}
+
+	planSpec, applySpec, err := svc.PlanAndApply(ws.ID, CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, PlanTask, planSpec.Identifier)
+	assert.Equal(t, ApplyTask, applySpec.Identifier)
+	// The apply applies the plan file created by the plan.
+	planFile := planSpec.Execution.Args[slices.Index(planSpec.Execution.Args, "-out")+1]
+	assert.Contains(t, applySpec.Execution.Args, planFile)
+	assert.NotContains(t, applySpec.Execution.Args, "-auto-approve")
+	assert.False(t, AutoApplies(applySpec))
+
+	ws.Protected = true
+	ws.ForbidAutoApply = true
+	_, _, err = svc.PlanAndApply(ws.ID, CreateOptions{})
+	assert.ErrorContains(t, err, "auto-apply is forbidden")
+}
+
+func TestPlan_PlanTaskSpec_Policies(t *testing.T) {
+	f, _, ws := setupTest(t)
+
+	run, err := f.newPlan(ws.ID, CreateOptions{planFile: true})
+	require.NoError(t, err)
+	assert.Nil(t, run.planTaskSpec().AdditionalExecution)
+
+	// The plan file is shown as part of the plan task in order to evaluate
+	// policies.
+	run.policies = []Policy{{Name: "no-deletions", Actions: []ChangeAction{DeleteAction}}}
+	spec := run.planTaskSpec()
+	require.NotNil(t, spec.AdditionalExecution)
+	assert.Equal(t, []string{"show"}, spec.AdditionalExecution.TerraformCommand)
+	assert.Equal(t, []string{"-json", run.planPath()}, spec.AdditionalExecution.Args)
+}
+
+func TestSplitShowOutput(t *testing.T) {
+	planOut, err := os.ReadFile("./testdata/plan_with_changes.txt")
+	require.NoError(t, err)
+	showOut, err := os.ReadFile("./testdata/show.json")
+	require.NoError(t, err)
+	// terraform show -json outputs a single line.
+	var compact bytes.Buffer
+	require.NoError(t, json.Compact(&compact, showOut))
+
+	out := append(slices.Clone(planOut), compact.Bytes()...)
+	out = append(out, '\n')
+
+	gotPlan, gotShow := splitShowOutput(out)
+	assert.Equal(t, string(planOut), string(gotPlan))
+	assert.JSONEq(t, string(showOut), string(gotShow))
+}
@@ -0,0 +1,181 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/leg100/pug/internal/workspace"
+)
+
+var (
+	// ErrPolicyViolation is returned when applying a plan that violates
+	// policies.
+	ErrPolicyViolation = errors.New("plan violates policies")
+	// ErrPoliciesUnchecked is returned when auto-applying a workspace to which
+	// policies apply, because an auto-apply cannot be checked against them.
+	ErrPoliciesUnchecked = errors.New("auto-apply cannot be checked against policies, apply a plan instead")
+)
+
+// Policy is a rule that the changes of a plan must satisfy before the plan can
+// be applied, e.g. no deletions in production, or no more than 10 changes. A
+// plan violates the policy if more of its resource changes match the policy
+// than the policy permits.
+type Policy struct {
+	// Name identifies the policy.
+	Name string `yaml:"name"`
+	// Workspaces are patterns matching the workspaces to which the policy
+	// applies, of the form <module>[:<workspace>], as used to protect
+	// workspaces. If empty then the policy applies to all workspaces.
+	Workspaces []string `yaml:"workspaces"`
+	// Types are globs matching the types of resources, e.g. aws_db_*. If
+	// empty then changes to resources of any type match.
+	Types []string `yaml:"types"`
+	// Addresses are globs matching resource addresses, e.g. module.db.*. If
+	// empty then changes to resources at any address match.
+	Addresses []string `yaml:"addresses"`
+	// Actions are the actions of changes that match: create, update, delete
+	// or replace. A replacement deletes a resource, so it also matches delete.
+	// If empty then changes of any action match.
+	Actions []ChangeAction `yaml:"actions"`
+	// MaxChanges is the maximum number of matching changes permitted, which
+	// defaults to zero.
+	MaxChanges int `yaml:"max_changes"`
+}
+
+var policyActions = []ChangeAction{CreateAction, UpdateAction, DeleteAction, ReplaceAction}
+
+// ValidatePolicies validates the policies, additionally checking that their
+// names are unique.
+func ValidatePolicies(policies []Policy) error {
+	names := make(map[string]bool, len(policies))
+	for _, p := range policies {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate policy name: %s", p.Name)
+		}
+		names[p.Name] = true
+	}
+	return nil
+}
+
+func (p Policy) validate() error {
+	if p.Name == "" {
+		return errors.New("policy name cannot be empty")
+	}
+	for _, pattern := range p.Workspaces {
+		if err := workspace.ValidatePattern(pattern); err != nil {
+			return fmt.Errorf("policy %s: invalid workspace pattern: %s: %w", p.Name, pattern, err)
+		}
+	}
+	for _, glob := range append(slices.Clone(p.Types), p.Addresses...) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("policy %s: invalid glob: %s: %w", p.Name, glob, err)
+		}
+	}
+	for _, action := range p.Actions {
+		if !slices.Contains(policyActions, action) {
+			return fmt.Errorf("policy %s: unknown action: %q", p.Name, action)
+		}
+	}
+	if p.MaxChanges < 0 {
+		return fmt.Errorf("policy %s: max changes cannot be negative", p.Name)
+	}
+	return nil
+}
+
+// appliesTo returns true if the policy applies to the workspace.
+func (p Policy) appliesTo(ws *workspace.Workspace) bool {
+	if len(p.Workspaces) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(p.Workspaces, ws.Matches)
+}
+
+// matches returns true if the resource change matches the policy.
+func (p Policy) matches(rc ResourceChange) bool {
+	action, ok := rc.Change.action()
+	if !ok {
+		return false
+	}
+	if len(p.Actions) > 0 && !slices.Contains(p.Actions, action) {
+		if action != ReplaceAction || !slices.Contains(p.Actions, DeleteAction) {
+			return false
+		}
+	}
+	if len(p.Types) > 0 && !matchAny(p.Types, rc.Type) {
+		return false
+	}
+	if len(p.Addresses) > 0 && !matchAny(p.Addresses, rc.Address) {
+		return false
+	}
+	return true
+}
+
+func matchAny(globs []string, name string) bool {
+	return slices.ContainsFunc(globs, func(glob string) bool {
+		ok, _ := path.Match(glob, name)
+		return ok
+	})
+}
+
+// policyNames returns the names of the policies, separated by commas.
+func policyNames(policies []Policy) string {
+	names := make([]string, len(policies))
+	for i, p := range policies {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// PolicyResult is the result of evaluating a policy against a plan.
+type PolicyResult struct {
+	// Policy is the name of the policy.
+	Policy string
+	// Passed is true if the plan satisfies the policy.
+	Passed bool
+	// Addresses are the addresses of the resources with changes matching the
+	// policy.
+	Addresses []string
+	// MaxChanges is the maximum number of matching changes the policy
+	// permits.
+	MaxChanges int
+}
+
+func (r PolicyResult) String() string {
+	if r.Passed {
+		return fmt.Sprintf("%s: passed", r.Policy)
+	}
+	return fmt.Sprintf("%s: failed: %d matching changes (max %d)", r.Policy, len(r.Addresses), r.MaxChanges)
+}
+
+// evaluate evaluates the policies against the plan file.
+func evaluate(policies []Policy, pf *planFile) []PolicyResult {
+	results := make([]PolicyResult, len(policies))
+	for i, p := range policies {
+		result := PolicyResult{Policy: p.Name, MaxChanges: p.MaxChanges}
+		for _, rc := range pf.ResourceChanges {
+			if p.matches(rc) {
+				result.Addresses = append(result.Addresses, rc.Address)
+			}
+		}
+		result.Passed = len(result.Addresses) <= p.MaxChanges
+		results[i] = result
+	}
+	return results
+}
+
+// Violations returns the names of the policies the plan violates.
+func Violations(results []PolicyResult) []string {
+	var names []string
+	for _, result := range results {
+		if !result.Passed {
+			names = append(names, result.Policy)
+		}
+	}
+	return names
+}
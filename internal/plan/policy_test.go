@@ -0,0 +1,117 @@
+package plan
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Evaluate(t *testing.T) {
+	b, err := os.ReadFile("./testdata/show.json")
+	require.NoError(t, err)
+	var pf planFile
+	require.NoError(t, json.Unmarshal(b, &pf))
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   PolicyResult
+	}{
+		{
+			"no deletions",
+			Policy{Name: "p", Actions: []ChangeAction{DeleteAction}},
+			PolicyResult{Policy: "p", Addresses: []string{"module.db.aws_db_instance.main", "aws_s3_bucket.logs"}},
+		},
+		{
+			"no replacements of databases",
+			Policy{Name: "p", Types: []string{"aws_db_*"}, Actions: []ChangeAction{ReplaceAction}},
+			PolicyResult{Policy: "p", Addresses: []string{"module.db.aws_db_instance.main"}},
+		},
+		{
+			"no changes within module",
+			Policy{Name: "p", Addresses: []string{"module.db.*"}},
+			PolicyResult{Policy: "p", Addresses: []string{"module.db.aws_db_instance.main"}},
+		},
+		{
+			"within max changes",
+			Policy{Name: "p", MaxChanges: 4},
+			PolicyResult{Policy: "p", Passed: true, MaxChanges: 4, Addresses: []string{"aws_instance.web", "aws_security_group.web", "module.db.aws_db_instance.main", "aws_s3_bucket.logs"}},
+		},
+		{
+			"exceeds max changes",
+			Policy{Name: "p", Actions: []ChangeAction{CreateAction, UpdateAction}, MaxChanges: 1},
+			PolicyResult{Policy: "p", MaxChanges: 1, Addresses: []string{"aws_instance.web", "aws_security_group.web"}},
+		},
+		{
+			"no matching changes",
+			Policy{Name: "p", Types: []string{"google_*"}},
+			PolicyResult{Policy: "p", Passed: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluate([]Policy{tt.policy}, &pf)
+			assert.Equal(t, []PolicyResult{tt.want}, got)
+		})
+	}
+}
+
+func TestPolicy_AppliesTo(t *testing.T) {
+	ws, err := workspace.New(module.New(module.Options{Path: "a/b/c"}), "prod")
+	require.NoError(t, err)
+
+	assert.True(t, Policy{}.appliesTo(ws))
+	assert.True(t, Policy{Workspaces: []string{"**:prod"}}.appliesTo(ws))
+	assert.True(t, Policy{Workspaces: []string{"x/**", "a/**"}}.appliesTo(ws))
+	assert.False(t, Policy{Workspaces: []string{"**:dev"}}.appliesTo(ws))
+}
+
+func TestValidatePolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []Policy
+		wantErr  bool
+	}{
+		{"valid", []Policy{{Name: "a", Workspaces: []string{"**:prod"}, Actions: []ChangeAction{ReplaceAction}}}, false},
+		{"missing name", []Policy{{}}, true},
+		{"duplicate name", []Policy{{Name: "a"}, {Name: "a"}}, true},
+		{"invalid workspace pattern", []Policy{{Name: "a", Workspaces: []string{":prod"}}}, true},
+		{"invalid glob", []Policy{{Name: "a", Types: []string{"aws_["}}}, true},
+		{"unknown action", []Policy{{Name: "a", Actions: []ChangeAction{"no-op"}}}, true},
+		{"negative max changes", []Policy{{Name: "a", MaxChanges: -1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePolicies(tt.policies)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPlan_ApplyPlanTaskSpec_PolicyViolation(t *testing.T) {
+	f, _, ws := setupTest(t)
+
+	run, err := f.newPlan(ws.ID, CreateOptions{planFile: true})
+	require.NoError(t, err)
+	run.HasChanges = true
+	run.PolicyResults = []PolicyResult{
+		{Policy: "passes", Passed: true},
+		{Policy: "fails", Addresses: []string{"aws_instance.web"}},
+	}
+
+	_, err = run.applyPlanTaskSpec(nil, false)
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.ErrorContains(t, err, "fails")
+
+	_, err = run.applyPlanTaskSpec(nil, true)
+	assert.NoError(t, err)
+}
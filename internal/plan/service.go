@@ -3,6 +3,7 @@ package plan
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
@@ -36,6 +37,7 @@ type ServiceOptions struct {
 	Workdir    internal.Workdir
 	Logger     logging.Interface
 	Terragrunt bool
+	Policies   []Policy
 }
 
 type moduleGetter interface {
@@ -63,6 +65,7 @@ func NewService(opts ServiceOptions) *Service {
 			workspaces: opts.Workspaces,
 			broker:     broker,
 			terragrunt: opts.Terragrunt,
+			policies:   opts.Policies,
 		},
 	}
 }
@@ -106,9 +109,46 @@ func (s *Service) Plan(workspaceID resource.ID, opts CreateOptions) (task.Spec,
 	return plan.planTaskSpec(), nil
 }
 
+// PlanAndApply creates task specs to create a plan, as with Plan, and to then
+// apply the plan without review. The apply task must depend upon the plan
+// task. A plan that violates policies fails, canceling the apply. Protected
+// workspaces that forbid auto-apply cannot be planned and applied without
+// review either.
+func (s *Service) PlanAndApply(workspaceID resource.ID, opts CreateOptions) (task.Spec, task.Spec, error) {
+	ws, err := s.workspaces.Get(workspaceID)
+	if err != nil {
+		return task.Spec{}, task.Spec{}, err
+	}
+	if ws.ForbidAutoApply {
+		return task.Spec{}, task.Spec{}, fmt.Errorf("workspace %s is protected: auto-apply is forbidden, apply a plan instead", ws)
+	}
+	opts.planFile = true
+	plan, err := s.newPlan(workspaceID, opts)
+	if err != nil {
+		return task.Spec{}, task.Spec{}, err
+	}
+	plan.applyAfter = true
+	s.table.Add(plan.ID, plan)
+
+	return plan.planTaskSpec(), plan.newApplyTaskSpec(), nil
+}
+
 // Apply creates a task spec to auto-apply a plan, i.e. `terraform apply`. To
-// apply an existing plan, see ApplyPlan.
+// apply an existing plan, see ApplyPlan. Protected workspaces that forbid
+// auto-apply cannot be auto-applied. Nor can workspaces to which policies
+// apply, because an auto-apply cannot be checked against them, unless the
+// policies are overridden.
 func (s *Service) Apply(workspaceID resource.ID, opts CreateOptions) (task.Spec, error) {
+	ws, err := s.workspaces.Get(workspaceID)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	if ws.ForbidAutoApply {
+		return task.Spec{}, fmt.Errorf("workspace %s is protected: auto-apply is forbidden, apply a plan instead", ws)
+	}
+	if policies := s.policiesFor(ws); len(policies) > 0 && !opts.OverridePolicies {
+		return task.Spec{}, fmt.Errorf("%w: workspace %s: %s", ErrPoliciesUnchecked, ws, policyNames(policies))
+	}
 	plan, err := s.newPlan(workspaceID, opts)
 	if err != nil {
 		return task.Spec{}, err
@@ -118,20 +158,101 @@ func (s *Service) Apply(workspaceID resource.ID, opts CreateOptions) (task.Spec,
 
 // ApplyPlan creates a task spec to apply an existing plan, i.e. `terraform
 // apply existing.plan`. The taskID is the ID of a plan task, which must have
-// finished successfully.
+// finished successfully. A stale plan, i.e. one created against a state that
+// has since changed, cannot be applied; see Replan. Nor can a plan that
+// violates policies.
 func (s *Service) ApplyPlan(taskID resource.ID) (task.Spec, error) {
-	planTask, err := s.tasks.Get(taskID)
+	plan, err := s.getApplyable(taskID)
 	if err != nil {
 		return task.Spec{}, err
 	}
-	if err := IsApplyable(planTask); err != nil {
+	return plan.applyPlanTaskSpec(s.pullState, false)
+}
+
+// CheckPlans checks whether the plans created by the plan tasks with the given
+// IDs can be applied, returning an error for each plan, in the same order,
+// which is nil if the plan can be applied. Checking for a stale plan runs a
+// task retrieving the workspace's current state, so plans are checked
+// concurrently, leaving the task runner to limit how many tasks run at once.
+func (s *Service) CheckPlans(taskIDs ...resource.ID) []error {
+	var (
+		errs = make([]error, len(taskIDs))
+		wg   sync.WaitGroup
+	)
+	for i, id := range taskIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.ApplyPlan(id)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// ApplyCheckedPlan is the same as ApplyPlan but for a plan that has already
+// been checked with CheckPlans, and so does not check again whether it is
+// stale. If overridePolicies is true then the plan is applied even if it
+// violates policies.
+func (s *Service) ApplyCheckedPlan(taskID resource.ID, overridePolicies bool) (task.Spec, error) {
+	plan, err := s.getApplyable(taskID)
+	if err != nil {
 		return task.Spec{}, err
 	}
+	return plan.checkedApplyPlanTaskSpec(overridePolicies)
+}
+
+// getApplyable retrieves the plan created by the plan task with the given ID,
+// returning an error if the plan cannot be applied independently.
+func (s *Service) getApplyable(taskID resource.ID) (*plan, error) {
+	planTask, err := s.tasks.Get(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := IsApplyable(planTask); err != nil {
+		return nil, err
+	}
+	plan, err := s.getByTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.applyAfter {
+		return nil, errors.New("plan is applied by its workflow")
+	}
+	return plan, nil
+}
+
+// Replan creates a task spec to create a new plan, for the same workspace and
+// with the same options as the plan created by the plan task with the given
+// ID, e.g. to replace a stale plan.
+func (s *Service) Replan(taskID resource.ID) (task.Spec, error) {
 	plan, err := s.getByTaskID(taskID)
 	if err != nil {
 		return task.Spec{}, err
 	}
-	return plan.applyTaskSpec()
+	return s.Plan(plan.WorkspaceID, CreateOptions{
+		Destroy:     plan.Destroy,
+		TargetAddrs: plan.TargetAddrs,
+	})
+}
+
+// Policies returns the policies that apply to the workspace.
+func (s *Service) Policies(workspaceID resource.ID) ([]Policy, error) {
+	ws, err := s.workspaces.Get(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.policiesFor(ws), nil
+}
+
+// PolicyResults returns the results of evaluating policies against the plan
+// created by the plan task with the given ID.
+func (s *Service) PolicyResults(taskID resource.ID) ([]PolicyResult, error) {
+	plan, err := s.getByTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	return plan.PolicyResults, nil
 }
 
 func IsApplyable(t *task.Task) error {
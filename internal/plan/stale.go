@@ -0,0 +1,128 @@
+package plan
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+)
+
+// ErrStalePlan is returned when applying a plan that was created against a
+// state that has since changed, e.g. because someone else applied changes in
+// the meantime.
+var ErrStalePlan = errors.New("plan is stale")
+
+// StateVersion identifies a version of a workspace's state.
+type StateVersion struct {
+	// Serial is incremented whenever the state changes. It is -1 if there is
+	// no state.
+	Serial int64
+	// Lineage is assigned when the state is first created.
+	Lineage string
+}
+
+func (v StateVersion) String() string {
+	if v.Serial == -1 {
+		return "no state"
+	}
+	return fmt.Sprintf("serial %d of lineage %s", v.Serial, v.Lineage)
+}
+
+// pullStateTimeout is the maximum duration for which retrieving a workspace's
+// current state may run.
+var pullStateTimeout = 2 * time.Minute
+
+// pullState retrieves the version of the workspace's current state, i.e.
+// `terraform state pull`. It is run as a task, so that, like any other task,
+// it is subject to the maximum number of running tasks and to concurrency
+// limits, and it is given a timeout.
+func (s *Service) pullState(r *plan) (StateVersion, error) {
+	t, err := s.tasks.Create(task.Spec{
+		ModuleID:    &r.ModuleID,
+		WorkspaceID: &r.WorkspaceID,
+		Path:        r.ModulePath,
+		Env:         r.envs,
+		Execution: task.Execution{
+			TerraformCommand: []string{"state", "pull"},
+		},
+		JSON:        true,
+		Short:       true,
+		Wait:        true,
+		Timeout:     pullStateTimeout,
+		Description: "state pull (check plan)",
+	})
+	if err != nil {
+		return StateVersion{}, fmt.Errorf("pulling state: %w", err)
+	}
+	out, err := io.ReadAll(t.NewReader(false))
+	if err != nil {
+		return StateVersion{}, fmt.Errorf("pulling state: %w", err)
+	}
+	return parseStateVersion(out)
+}
+
+// readPlanState retrieves the version of the state against which a plan was
+// created, from the snapshot of the state embedded in the plan file.
+func readPlanState(path string) (StateVersion, error) {
+	// A plan file is a zip archive, with the state snapshot in the tfstate
+	// entry.
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return StateVersion{}, fmt.Errorf("reading plan file: %w", err)
+	}
+	defer r.Close()
+
+	f, err := r.Open("tfstate")
+	if errors.Is(err, fs.ErrNotExist) {
+		return StateVersion{Serial: -1}, nil
+	} else if err != nil {
+		return StateVersion{}, fmt.Errorf("reading plan file: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return StateVersion{}, fmt.Errorf("reading plan file: %w", err)
+	}
+	return parseStateVersion(data)
+}
+
+func parseStateVersion(data []byte) (StateVersion, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return StateVersion{Serial: -1}, nil
+	}
+	var file state.StateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return StateVersion{}, fmt.Errorf("parsing state: %w", err)
+	}
+	// Without a lineage the state is yet to be persisted, i.e. there is no
+	// state.
+	if file.Lineage == "" {
+		return StateVersion{Serial: -1}, nil
+	}
+	return StateVersion{Serial: file.Serial, Lineage: file.Lineage}, nil
+}
+
+// checkState checks that the workspace's current state, retrieved with pull,
+// is the same version as the state against which the plan was created. The
+// check is skipped if the latter is unknown.
+func (r *plan) checkState(pull func(*plan) (StateVersion, error)) error {
+	if r.PriorState == nil {
+		return nil
+	}
+	current, err := pull(r)
+	if err != nil {
+		return fmt.Errorf("checking plan is up to date: %w", err)
+	}
+	if current != *r.PriorState {
+		return fmt.Errorf("%w: state has changed since planning: planned against %s but now %s", ErrStalePlan, r.PriorState, current)
+	}
+	return nil
+}
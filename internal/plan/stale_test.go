@@ -0,0 +1,92 @@
+package plan
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan_CheckState(t *testing.T) {
+	tests := []struct {
+		name    string
+		prior   *StateVersion
+		state   string
+		wantErr error
+	}{
+		{"unchanged", &StateVersion{Serial: 3, Lineage: "abc"}, `{"serial": 3, "lineage": "abc"}`, nil},
+		{"new serial", &StateVersion{Serial: 3, Lineage: "abc"}, `{"serial": 4, "lineage": "abc"}`, ErrStalePlan},
+		{"new lineage", &StateVersion{Serial: 3, Lineage: "abc"}, `{"serial": 3, "lineage": "def"}`, ErrStalePlan},
+		{"still no state", &StateVersion{Serial: -1}, ``, nil},
+		{"state created", &StateVersion{Serial: -1}, `{"serial": 1, "lineage": "abc"}`, ErrStalePlan},
+		{"prior state unknown", nil, `{"serial": 4, "lineage": "abc"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fake program that outputs the state upon `state pull`.
+			dir := t.TempDir()
+			statePath := filepath.Join(dir, "state.json")
+			require.NoError(t, os.WriteFile(statePath, []byte(tt.state), 0o644))
+			program := filepath.Join(dir, "terraform")
+			require.NoError(t, os.WriteFile(program, []byte("#!/bin/sh\ncat \"$STATE_PATH\"\n"), 0o755))
+			tasks := task.NewService(task.ServiceOptions{Program: program, Logger: logging.Discard})
+			task.StartEnqueuer(tasks)
+			task.StartRunner(context.Background(), logging.Discard, tasks, task.RunnerOptions{MaxTasks: 1})
+			svc := &Service{tasks: tasks}
+
+			r := &plan{
+				PriorState: tt.prior,
+				ModulePath: dir,
+				envs:       []string{"STATE_PATH=" + statePath},
+			}
+			err := r.checkState(svc.pullState)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReadPlanState(t *testing.T) {
+	tests := []struct {
+		name  string
+		state *string
+		want  StateVersion
+	}{
+		{"state", internal.String(`{"serial": 3, "lineage": "abc"}`), StateVersion{Serial: 3, Lineage: "abc"}},
+		{"state yet to be persisted", internal.String(`{"serial": 0, "lineage": ""}`), StateVersion{Serial: -1}},
+		{"no state", nil, StateVersion{Serial: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Plan file is a zip archive with the state in the tfstate entry.
+			path := filepath.Join(t.TempDir(), "plan")
+			f, err := os.Create(path)
+			require.NoError(t, err)
+			w := zip.NewWriter(f)
+			_, err = w.Create("tfplan")
+			require.NoError(t, err)
+			if tt.state != nil {
+				fw, err := w.Create("tfstate")
+				require.NoError(t, err)
+				_, err = fw.Write([]byte(*tt.state))
+				require.NoError(t, err)
+			}
+			require.NoError(t, w.Close())
+			require.NoError(t, f.Close())
+
+			got, err := readPlanState(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
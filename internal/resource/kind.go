@@ -13,6 +13,7 @@ const (
 	LogAttr
 	State
 	StateResource
+	Diagnostic
 )
 
 func (k Kind) String() string {
@@ -27,5 +28,6 @@ func (k Kind) String() string {
 		"attr",
 		"state",
 		"res",
+		"diag",
 	}[k]
 }
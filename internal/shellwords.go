@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+)
+
+// SplitWords splits the string into words in the manner of a POSIX shell,
+// respecting single and double quotes and backslash escapes, e.g. `-var
+// 'name=a b'` is split into `-var` and `name=a b`. Variables and other
+// expansions are not supported.
+func SplitWords(s string) ([]string, error) {
+	var (
+		words []string
+		word  strings.Builder
+		// inWord is true if a word has been started, which may yet be empty,
+		// e.g. ''.
+		inWord bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\\':
+			inWord = true
+			if i+1 < len(s) {
+				i++
+				word.WriteByte(s[i])
+			}
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			for i++; ; i++ {
+				if i == len(s) {
+					return nil, errors.New("unterminated double quote")
+				}
+				if s[i] == '"' {
+					break
+				}
+				// Within double quotes a backslash only escapes characters
+				// that are otherwise special.
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(s[i])
+			}
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// JoinWords joins the words into a string, quoting each word where necessary
+// so that SplitWords, or a POSIX shell, splits the string back into the same
+// words.
+func JoinWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = quoteWord(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteWord(word string) string {
+	if word == "" {
+		return "''"
+	}
+	safe := strings.IndexFunc(word, func(r rune) bool {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return false
+		case strings.ContainsRune("-_./:=,+@%", r):
+			return false
+		default:
+			return true
+		}
+	}) < 0
+	if safe {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"fields", "  plan -input=false\t-lock ", []string{"plan", "-input=false", "-lock"}, false},
+		{"single quotes", `-var 'name=a b'`, []string{"-var", "name=a b"}, false},
+		{"double quotes", `--config "a b.hcl"`, []string{"--config", "a b.hcl"}, false},
+		{"escaped double quote", `"say \"hi\" \n"`, []string{`say "hi" \n`}, false},
+		{"backslash", `a\ b c\'d`, []string{"a b", "c'd"}, false},
+		{"adjacent quotes", `-var='tags={"a"="b c"}'`, []string{`-var=tags={"a"="b c"}`}, false},
+		{"empty word", `'' ""`, []string{"", ""}, false},
+		{"unterminated single quote", `'a b`, nil, true},
+		{"unterminated double quote", `"a b`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitWords(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJoinWords(t *testing.T) {
+	words := []string{"plan", "-input=false", "-var", "name=a b", "it's", "", `{"a"="b"}`}
+
+	joined := JoinWords(words)
+	assert.Equal(t, `plan -input=false -var 'name=a b' 'it'\''s' '' '{"a"="b"}'`, joined)
+
+	got, err := SplitWords(joined)
+	require.NoError(t, err)
+	assert.Equal(t, words, got)
+}
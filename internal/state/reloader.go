@@ -2,11 +2,16 @@ package state
 
 import (
 	"fmt"
+	"slices"
 
+	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/task"
 )
 
+// ReloadTask is the identifier of a task that reloads state.
+const ReloadTask task.Identifier = "state-reload"
+
 type reloader struct {
 	*Service
 }
@@ -15,6 +20,7 @@ type reloader struct {
 // workspace.
 func (r *reloader) Reload(workspaceID resource.ID) (task.Spec, error) {
 	return r.createTaskSpec(workspaceID, task.Spec{
+		Identifier: ReloadTask,
 		Execution: task.Execution{
 			TerraformCommand: []string{"state", "pull"},
 		},
@@ -53,3 +59,37 @@ func (s *Service) CreateReloadTask(workspaceID resource.ID) (*task.Task, error)
 	}
 	return task, nil
 }
+
+// ReloadUponLocalStateChange reloads the state of a workspace whenever its
+// local state file is changed outside of pug. If pug has an active blocking
+// task for the workspace's module, e.g. an apply, then the state is not
+// reloaded, because the task itself is responsible for altering the state and
+// reloading it afterwards. Nor is it reloaded if a reload is already
+// underway.
+func (s *Service) ReloadUponLocalStateChange(changes <-chan module.StateChange) {
+	for change := range changes {
+		mod, err := s.modules.Get(change.ModuleID)
+		if err != nil {
+			continue
+		}
+		ws, err := s.workspaces.GetByName(mod.Path, change.Workspace)
+		if err != nil {
+			// Workspace not yet known to pug.
+			continue
+		}
+		active := s.tasks.List(task.ListOptions{
+			Status: []task.Status{task.Pending, task.Queued, task.Running},
+		})
+		if slices.ContainsFunc(active, func(t *task.Task) bool {
+			if t.ModuleID == nil || *t.ModuleID != mod.ID {
+				return false
+			}
+			return t.Blocking || (t.Identifier == ReloadTask && t.WorkspaceID != nil && *t.WorkspaceID == ws.ID)
+		}) {
+			continue
+		}
+		if _, err := s.CreateReloadTask(ws.ID); err != nil {
+			s.logger.Error("reloading state", "error", err, "workspace", ws)
+		}
+	}
+}
@@ -62,13 +62,17 @@ func (s *Service) GetResource(resourceID resource.ID) (*Resource, error) {
 	return nil, resource.ErrNotFound
 }
 
+// DeleteTask identifies a task removing resources from state.
+const DeleteTask task.Identifier = "state rm"
+
 func (s *Service) Delete(workspaceID resource.ID, addrs ...ResourceAddress) (task.Spec, error) {
 	addrStrings := make([]string, len(addrs))
 	for i, addr := range addrs {
 		addrStrings[i] = string(addr)
 	}
 	return s.createTaskSpec(workspaceID, task.Spec{
-		Blocking: true,
+		Identifier: DeleteTask,
+		Blocking:   true,
 		Execution: task.Execution{
 			TerraformCommand: []string{"state", "rm"},
 			Args:             addrStrings,
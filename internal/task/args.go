@@ -0,0 +1,74 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/leg100/pug/internal"
+)
+
+// Command returns the command line that would be run for a task created from
+// the spec, excluding any user-supplied args.
+func (s *Service) Command(spec Spec) []string {
+	if spec.Execution.Program != "" {
+		return append([]string{spec.Execution.Program}, spec.Execution.Args...)
+	}
+	command := append([]string{s.program}, spec.Execution.TerraformCommand...)
+	return append(command, spec.Execution.Args...)
+}
+
+// CommandLine returns the command line of Command as a string, quoting args
+// where necessary, e.g. an arg containing spaces, so that it can be edited and
+// passed to WithArgs.
+func (s *Service) CommandLine(spec Spec) string {
+	return internal.JoinWords(s.Command(spec))
+}
+
+// WithArgs returns copies of the specs with their args replaced by the args
+// of an edited command line. The command line is that of the first spec (see
+// CommandLine) as edited by the user, and only its args can be edited. The
+// command line is split into args as a shell would, respecting quotes. The
+// edited args are applied to the other specs too, retaining the args that
+// differ from spec to spec, e.g. the path to a plan file.
+func (s *Service) WithArgs(specs []Spec, edited string) ([]Spec, error) {
+	if len(specs) == 0 {
+		return nil, errors.New("no tasks to run")
+	}
+	var (
+		original = s.Command(specs[0])
+		prefix   = len(original) - len(specs[0].Execution.Args)
+	)
+	fields, err := internal.SplitWords(edited)
+	if err != nil {
+		return nil, fmt.Errorf("parsing command line: %w", err)
+	}
+	if len(fields) < prefix || !slices.Equal(fields[:prefix], original[:prefix]) {
+		return nil, fmt.Errorf("only the args can be edited: %s", internal.JoinWords(original[:prefix]))
+	}
+	args := fields[prefix:]
+
+	edits := make([]Spec, len(specs))
+	for i, spec := range specs {
+		// Map the args of the first spec to those of this spec where they
+		// differ.
+		if len(spec.Execution.Args) != len(specs[0].Execution.Args) {
+			return nil, errors.New("cannot edit args of tasks with differing args")
+		}
+		substitutes := make(map[string]string)
+		for j, arg := range specs[0].Execution.Args {
+			if arg != spec.Execution.Args[j] {
+				substitutes[arg] = spec.Execution.Args[j]
+			}
+		}
+		spec.Execution.Args = make([]string, len(args))
+		for j, arg := range args {
+			if sub, ok := substitutes[arg]; ok {
+				arg = sub
+			}
+			spec.Execution.Args[j] = arg
+		}
+		edits[i] = spec
+	}
+	return edits, nil
+}
@@ -0,0 +1,132 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Command(t *testing.T) {
+	svc := &Service{factory: &factory{program: "terraform"}}
+
+	tests := []struct {
+		name string
+		spec Spec
+		want []string
+	}{
+		{
+			name: "terraform command",
+			spec: Spec{Execution: Execution{
+				TerraformCommand: []string{"state", "rm"},
+				Args:             []string{"random_pet.pet"},
+			}},
+			want: []string{"terraform", "state", "rm", "random_pet.pet"},
+		},
+		{
+			name: "program",
+			spec: Spec{Execution: Execution{
+				Program: "infracost",
+				Args:    []string{"breakdown"},
+			}},
+			want: []string{"infracost", "breakdown"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, svc.Command(tt.spec))
+		})
+	}
+}
+
+func TestService_CommandLine(t *testing.T) {
+	svc := &Service{factory: &factory{program: "terraform"}}
+
+	spec := Spec{Execution: Execution{
+		TerraformCommand: []string{"plan"},
+		Args:             []string{"-input=false", "-var", `tags={"team"="a b"}`},
+	}}
+	line := svc.CommandLine(spec)
+	assert.Equal(t, `terraform plan -input=false -var 'tags={"team"="a b"}'`, line)
+
+	// The command line can be passed back unedited.
+	got, err := svc.WithArgs([]Spec{spec}, line)
+	require.NoError(t, err)
+	assert.Equal(t, spec.Execution.Args, got[0].Execution.Args)
+}
+
+func TestService_WithArgs(t *testing.T) {
+	svc := &Service{factory: &factory{program: "terraform"}}
+
+	plan := func(path string) Spec {
+		return Spec{Path: path, Execution: Execution{
+			TerraformCommand: []string{"plan"},
+			Args:             []string{"-input=false", "-out", path + "/plan.out"},
+		}}
+	}
+	specs := []Spec{plan("a"), plan("b")}
+
+	tests := []struct {
+		name    string
+		edited  string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:   "add args",
+			edited: "terraform plan -input=false -out a/plan.out -parallelism=2 -var foo=bar",
+			want: [][]string{
+				{"-input=false", "-out", "a/plan.out", "-parallelism=2", "-var", "foo=bar"},
+				{"-input=false", "-out", "b/plan.out", "-parallelism=2", "-var", "foo=bar"},
+			},
+		},
+		{
+			name:   "insert and remove args",
+			edited: "terraform plan -lock=false -out  a/plan.out",
+			want: [][]string{
+				{"-lock=false", "-out", "a/plan.out"},
+				{"-lock=false", "-out", "b/plan.out"},
+			},
+		},
+		{
+			name:   "quoted var",
+			edited: `terraform plan -input=false -out a/plan.out -var 'name=a b' -var="tags={\"team\"=\"x y\"}"`,
+			want: [][]string{
+				{"-input=false", "-out", "a/plan.out", "-var", "name=a b", `-var=tags={"team"="x y"}`},
+				{"-input=false", "-out", "b/plan.out", "-var", "name=a b", `-var=tags={"team"="x y"}`},
+			},
+		},
+		{
+			name:    "unterminated quote",
+			edited:  "terraform plan -var 'name=a b",
+			wantErr: true,
+		},
+		{
+			name:    "edit command",
+			edited:  "terraform apply -input=false",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			edited:  "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.WithArgs(specs, tt.edited)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.want))
+			for i, want := range tt.want {
+				assert.Equal(t, want, got[i].Execution.Args)
+				assert.Equal(t, specs[i].Path, got[i].Path)
+			}
+			// The original specs are left untouched.
+			assert.Equal(t, plan("a"), specs[0])
+		})
+	}
+}
@@ -1,87 +1,377 @@
 package task
 
 import (
-	"bytes"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+
+	"github.com/leg100/pug/internal/logging"
+)
+
+const (
+	// chunkSize is the maximum size of a chunk of output.
+	chunkSize = 32 << 10
+	// memorySize is the maximum number of bytes of the most recent output kept
+	// in memory. Older chunks are spilled to disk.
+	memorySize = 8 * chunkSize
+)
+
+// stream identifies the stream to which output is written.
+type stream int
+
+const (
+	stdoutStream stream = iota
+	stderrStream
 )
 
+// chunk is a contiguous piece of output written to a stream.
+type chunk struct {
+	stream stream
+	// data is the output, or nil if the chunk has been spilled to disk.
+	data []byte
+	// offset is the position of the chunk in the spill file, if the chunk has
+	// been spilled.
+	offset int64
+	size   int
+}
+
+// buffer is an append-only store of the output of a task's streams. Output is
+// stored in chunks, the most recent of which are kept in memory, whereas older
+// chunks are spilled to a file, keeping memory usage bounded. Readers and
+// streamers read across both transparently.
 type buffer struct {
-	buf *bytes.Buffer
+	mu     sync.Mutex
+	chunks []*chunk
+	// spilled is the number of chunks spilled to disk, which are always the
+	// oldest chunks.
+	spilled int
+	// memory is the number of bytes of output in chunks kept in memory.
+	memory int
+
+	// dir is the directory in which to create the spill file. If empty then
+	// chunks are never spilled.
+	dir string
+	// path is the path of the spill file, or empty if nothing has been
+	// spilled. The file is only opened whilst it is written to or read from.
+	path     string
+	fileSize int64
+	// spillErr is non-nil if spilling failed, in which case chunks are no
+	// longer spilled and instead kept in memory.
+	spillErr error
+	logger   logging.Interface
 
-	avail chan struct{}
-	mu    sync.Mutex
+	// mirror, if non-nil, is written a copy of all output written to the
+	// buffer. It is guarded by mirrorMu rather than mu, so that writing to it
+	// does not block readers. To write output to the mirror in the same order
+	// it is written to the buffer, mirrorMu is acquired before mu is released.
+	mirror   io.WriteCloser
+	mirrorMu sync.Mutex
+
+	// changed is closed and replaced whenever output is written or the buffer
+	// is closed, notifying streamers.
+	changed chan struct{}
+	closed  bool
 }
 
-func newBuffer() *buffer {
+func newBuffer(dir string, logger logging.Interface) *buffer {
+	if logger == nil {
+		logger = logging.Discard
+	}
 	return &buffer{
-		buf:   new(bytes.Buffer),
-		avail: make(chan struct{}, 1),
+		dir:     dir,
+		logger:  logger,
+		changed: make(chan struct{}),
 	}
 }
 
-func (b *buffer) Write(p []byte) (int, error) {
+// writer returns a writer that writes output to the stream.
+func (b *buffer) writer(s stream) io.Writer {
+	return &streamWriter{buffer: b, stream: s}
+}
+
+type streamWriter struct {
+	*buffer
+	stream stream
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	return w.write(w.stream, p)
+}
+
+func (b *buffer) write(s stream, p []byte) (int, error) {
 	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.append(s, p)
+	b.mirrorMu.Lock()
+	b.mu.Unlock()
+	defer b.mirrorMu.Unlock()
 
-	n, err := b.buf.Write(p)
+	if b.mirror != nil {
+		if _, err := b.mirror.Write(p); err != nil {
+			// Stop mirroring rather than fail the write.
+			b.mirror.Close()
+			b.mirror = nil
+		}
+	}
+	return len(p), nil
+}
+
+// append appends the output to the stream. The buffer lock must be held.
+func (b *buffer) append(s stream, p []byte) {
+	for len(p) > 0 {
+		// Append to the last chunk if it belongs to the same stream and has
+		// room, otherwise start a new chunk.
+		var last *chunk
+		if len(b.chunks) > b.spilled {
+			last = b.chunks[len(b.chunks)-1]
+		}
+		if last == nil || last.stream != s || last.size == chunkSize {
+			last = &chunk{stream: s}
+			b.chunks = append(b.chunks, last)
+		}
+		m := min(len(p), chunkSize-last.size)
+		last.data = append(last.data, p[:m]...)
+		last.size += m
+		b.memory += m
+		p = p[m:]
+	}
+	b.spill()
+
+	// Let streamers know there is output to be read.
+	if !b.closed {
+		close(b.changed)
+		b.changed = make(chan struct{})
+	}
+}
+
+// spill moves the oldest chunks to disk until no more than memorySize bytes
+// remain in memory. The most recent chunk, to which output is appended, is
+// always kept in memory.
+func (b *buffer) spill() {
+	if b.dir == "" || b.spillErr != nil {
+		return
+	}
+	if b.memory <= memorySize || len(b.chunks)-b.spilled < 2 {
+		return
+	}
+	if err := b.spillChunks(); err != nil {
+		b.spillErr = err
+		b.logger.Error("spilling task output to disk, keeping output in memory instead", "error", err)
+	}
+}
+
+func (b *buffer) spillChunks() error {
+	if b.path == "" {
+		if err := os.MkdirAll(b.dir, 0o755); err != nil {
+			return err
+		}
+		// The process ID is included in the name so that the files of
+		// processes that have since exited can be identified and removed.
+		f, err := os.CreateTemp(b.dir, fmt.Sprintf("task-%d-*.out", os.Getpid()))
+		if err != nil {
+			return err
+		}
+		b.path = f.Name()
+		f.Close()
+	}
+	f, err := os.OpenFile(b.path, os.O_WRONLY, 0)
 	if err != nil {
-		return n, err
+		return err
 	}
-	// Let streamers know there are now available bytes to be read.
-	select {
-	case b.avail <- struct{}{}:
-	default:
+	defer f.Close()
+
+	for b.memory > memorySize && len(b.chunks)-b.spilled > 1 {
+		c := b.chunks[b.spilled]
+		if _, err := f.WriteAt(c.data, b.fileSize); err != nil {
+			return err
+		}
+		c.offset = b.fileSize
+		c.data = nil
+		b.fileSize += int64(c.size)
+		b.memory -= c.size
+		b.spilled++
 	}
-	return n, nil
+	return f.Close()
 }
 
-// NewReader returns a copy of the buffer to read from.
-func (b *buffer) NewReader() io.Reader {
+// sweepSpillFiles removes the spill files in dir left behind by processes that
+// are no longer running.
+func sweepSpillFiles(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "task-*.out"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		// Spill files are named task-<pid>-<random>.out. Files named otherwise
+		// pre-date the naming scheme and are removed too.
+		pid, _, _ := strings.Cut(strings.TrimPrefix(filepath.Base(path), "task-"), "-")
+		if pid, err := strconv.Atoi(pid); err == nil && (pid == os.Getpid() || processExists(pid)) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// mirrorTo writes a copy of all subsequent output to w, which is closed when
+// the buffer is closed.
+func (b *buffer) mirrorTo(w io.WriteCloser) {
+	b.mirrorMu.Lock()
+	defer b.mirrorMu.Unlock()
+
+	b.mirror = w
+}
+
+// readChunk reads from the chunk into p, starting at offset within the chunk.
+// The buffer lock must be held.
+func (b *buffer) readChunk(c *chunk, p []byte, offset int) (int, error) {
+	if c.data != nil {
+		return copy(p, c.data[offset:]), nil
+	}
+	f, err := os.Open(b.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.ReadAt(p, c.offset+int64(offset))
+}
+
+// NewReader returns a reader of the output written to the buffer thus far.
+// Set combined to true to read the output of all streams, or to false to read
+// only stdout.
+func (b *buffer) NewReader(combined bool) io.Reader {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	r := new(bytes.Buffer)
-	r.Write(b.buf.Bytes())
+	r := &reader{buffer: b, combined: combined, endChunk: len(b.chunks) - 1}
+	if r.endChunk >= 0 {
+		r.endSize = b.chunks[r.endChunk].size
+	}
 	return r
 }
 
-// Stream buffer as it is written to. The return channel is closed when the
-// buffer is closed.
-func (b *buffer) Stream() <-chan []byte {
-	var (
-		offset int
-		ch     = make(chan []byte)
-	)
+// reader reads output from a buffer.
+type reader struct {
+	*buffer
+	combined bool
+	// live is true if the reader reads output as it is written, otherwise it
+	// stops at the end of the output at the time it was created, given by
+	// endChunk and endSize.
+	live     bool
+	endChunk int
+	endSize  int
+	// chunk and offset is the current position of the reader.
+	chunk  int
+	offset int
+}
 
-	copyBytes := func() []byte {
-		b.mu.Lock()
-		byts := b.buf.Bytes()
-		size := b.buf.Len() - offset
-		dst := make([]byte, size)
-		offset += copy(dst, byts[offset:])
-		b.mu.Unlock()
-		return dst
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	go func() {
-		if b := copyBytes(); len(b) > 0 {
-			ch <- b
+	for {
+		endChunk, endSize := r.endChunk, r.endSize
+		if r.live {
+			endChunk, endSize = len(r.chunks)-1, -1
 		}
+		if r.chunk > endChunk {
+			return 0, io.EOF
+		}
+		c := r.chunks[r.chunk]
+		size := c.size
+		if r.chunk == endChunk && endSize >= 0 {
+			size = endSize
+		}
+		if (!r.combined && c.stream != stdoutStream) || r.offset >= size {
+			if r.live && r.chunk == endChunk {
+				// Await further output to the last chunk.
+				return 0, io.EOF
+			}
+			r.chunk++
+			r.offset = 0
+			continue
+		}
+		n, err := r.readChunk(c, p[:min(len(p), size-r.offset)], r.offset)
+		r.offset += n
+		return n, err
+	}
+}
+
+// Stream the combined output of the buffer as it is written to, starting with
+// the output written thus far. The returned channel is closed when the buffer
+// is closed.
+func (b *buffer) Stream() <-chan []byte {
+	var (
+		ch = make(chan []byte)
+		r  = &reader{buffer: b, combined: true, live: true}
+	)
+	go func() {
+		defer close(ch)
+
+		buf := make([]byte, chunkSize)
 		for {
-			_, ok := <-b.avail
-			if b := copyBytes(); len(b) > 0 {
-				ch <- b
+			// Retrieve notification channel before reading so that output
+			// written after reading is not missed.
+			b.mu.Lock()
+			changed, closed := b.changed, b.closed
+			b.mu.Unlock()
+
+			n, err := r.Read(buf)
+			if n > 0 {
+				out := make([]byte, n)
+				copy(out, buf)
+				ch <- out
+				continue
+			}
+			if err != io.EOF {
+				return
 			}
-			if !ok {
-				close(ch)
+			if closed {
 				return
 			}
+			<-changed
 		}
 	}()
 	return ch
 }
 
+// Close the buffer, indicating no further output is to be written.
 func (b *buffer) Close() {
-	close(b.avail)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.changed)
+	// Close the mirror once any output written beforehand has been mirrored.
+	b.mirrorMu.Lock()
+	b.mu.Unlock()
+	defer b.mirrorMu.Unlock()
+
+	if b.mirror != nil {
+		b.mirror.Close()
+		b.mirror = nil
+	}
+}
+
+// remove the spill file, if any. The buffer can no longer be read once removed.
+func (b *buffer) remove() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.path == "" {
+		return nil
+	}
+	path := b.path
+	b.path = ""
+	return os.Remove(path)
 }
@@ -1,8 +1,14 @@
 package task
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/leg100/pug/internal/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -12,12 +18,12 @@ import (
 func TestBuffer_NewReader(t *testing.T) {
 	t.Parallel()
 
-	buf := newBuffer()
-	_, err := buf.Write([]byte("hello world"))
+	buf := newBuffer("", logging.Discard)
+	_, err := buf.writer(stdoutStream).Write([]byte("hello world"))
 	require.NoError(t, err)
 
-	r1 := buf.NewReader()
-	r2 := buf.NewReader()
+	r1 := buf.NewReader(true)
+	r2 := buf.NewReader(true)
 
 	got := make([]byte, len("hello world"))
 
@@ -33,16 +39,16 @@ func TestBuffer_NewReader(t *testing.T) {
 func TestBuffer_Stream(t *testing.T) {
 	t.Parallel()
 
-	buf := newBuffer()
+	buf := newBuffer("", logging.Discard)
 	ch := buf.Stream()
 
-	_, err := buf.Write([]byte("hello"))
+	_, err := buf.writer(stdoutStream).Write([]byte("hello"))
 	require.NoError(t, err)
 
 	got := <-ch
 	assert.Equal(t, "hello", string(got))
 
-	_, err = buf.Write([]byte("world"))
+	_, err = buf.writer(stdoutStream).Write([]byte("world"))
 	require.NoError(t, err)
 
 	got = <-ch
@@ -53,3 +59,187 @@ func TestBuffer_Stream(t *testing.T) {
 	got = <-ch
 	assert.Nil(t, got)
 }
+
+// TestBuffer_NewReader_Streams tests reading only stdout, or all streams.
+func TestBuffer_NewReader_Streams(t *testing.T) {
+	t.Parallel()
+
+	buf := newBuffer("", logging.Discard)
+	_, _ = buf.writer(stdoutStream).Write([]byte("foo\n"))
+	_, _ = buf.writer(stderrStream).Write([]byte("err\n"))
+	_, _ = buf.writer(stdoutStream).Write([]byte("bar\n"))
+
+	stdout, err := io.ReadAll(buf.NewReader(false))
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\n", string(stdout))
+
+	combined, err := io.ReadAll(buf.NewReader(true))
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nerr\nbar\n", string(combined))
+}
+
+// TestBuffer_NewReader_Snapshot tests that a reader only reads the output
+// written before the reader was created.
+func TestBuffer_NewReader_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	buf := newBuffer("", logging.Discard)
+	_, _ = buf.writer(stdoutStream).Write([]byte("hello"))
+	r := buf.NewReader(true)
+	_, _ = buf.writer(stdoutStream).Write([]byte(" world"))
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+}
+
+// TestBuffer_Spill tests that older output is spilled to disk, and that it can
+// be read and streamed transparently.
+func TestBuffer_Spill(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	buf := newBuffer(dir, logging.Discard)
+
+	// Write enough output to spill several chunks, alternating between streams
+	// and varying the size of writes.
+	var stdout, combined bytes.Buffer
+	for i := 0; i < 24; i++ {
+		s := stdoutStream
+		if i%3 == 0 {
+			s = stderrStream
+		}
+		p := bytes.Repeat([]byte{byte('a' + i%26)}, chunkSize/2+i*100)
+		_, err := buf.writer(s).Write(p)
+		require.NoError(t, err)
+		if s == stdoutStream {
+			stdout.Write(p)
+		}
+		combined.Write(p)
+	}
+	buf.Close()
+
+	// Spill file has been created.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// Memory only retains the most recent output.
+	assert.LessOrEqual(t, inMemory(buf), memorySize)
+
+	got, err := io.ReadAll(buf.NewReader(false))
+	require.NoError(t, err)
+	assert.Equal(t, stdout.Bytes(), got)
+
+	got, err = io.ReadAll(buf.NewReader(true))
+	require.NoError(t, err)
+	assert.Equal(t, combined.Bytes(), got)
+
+	var streamed []byte
+	for b := range buf.Stream() {
+		streamed = append(streamed, b...)
+	}
+	assert.Equal(t, combined.Bytes(), streamed)
+
+	// Removing the buffer removes the spill file.
+	require.NoError(t, buf.remove())
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
+
+// TestBuffer_Spill_SmallWrites tests that memory is bounded by bytes, even when
+// many small writes alternate between streams, each creating a new chunk.
+func TestBuffer_Spill_SmallWrites(t *testing.T) {
+	t.Parallel()
+
+	buf := newBuffer(t.TempDir(), logging.Discard)
+
+	var combined bytes.Buffer
+	for i := 0; i < 2*memorySize/10; i++ {
+		s := stdoutStream
+		if i%2 == 0 {
+			s = stderrStream
+		}
+		p := []byte(fmt.Sprintf("line %03d\n", i%1000))
+		_, err := buf.writer(s).Write(p)
+		require.NoError(t, err)
+		combined.Write(p)
+	}
+	buf.Close()
+
+	assert.LessOrEqual(t, inMemory(buf), memorySize)
+	assert.Greater(t, buf.spilled, 0)
+
+	got, err := io.ReadAll(buf.NewReader(true))
+	require.NoError(t, err)
+	assert.Equal(t, combined.Bytes(), got)
+}
+
+func TestSweepSpillFiles(t *testing.T) {
+	dir := t.TempDir()
+	create := func(name string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+		return path
+	}
+	ours := create(fmt.Sprintf("task-%d-123.out", os.Getpid()))
+	// Process IDs cannot exceed 2^22 on linux, nor 99999 on darwin.
+	stale := create("task-99999999-123.out")
+	legacy := create("task-123.out")
+
+	require.NoError(t, sweepSpillFiles(dir))
+
+	assert.FileExists(t, ours)
+	assert.NoFileExists(t, stale)
+	assert.NoFileExists(t, legacy)
+}
+
+func inMemory(buf *buffer) (size int) {
+	for _, c := range buf.chunks {
+		size += len(c.data)
+	}
+	return size
+}
+
+// blockingWriter blocks writes until unblocked.
+type blockingWriter struct {
+	bytes.Buffer
+	writing chan struct{}
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.writing <- struct{}{}
+	<-w.unblock
+	return w.Buffer.Write(p)
+}
+
+func (w *blockingWriter) Close() error { return nil }
+
+// TestBuffer_Mirror tests that a slow mirror does not block readers of the
+// buffer.
+func TestBuffer_Mirror(t *testing.T) {
+	t.Parallel()
+
+	buf := newBuffer("", logging.Discard)
+	mirror := &blockingWriter{writing: make(chan struct{}), unblock: make(chan struct{})}
+	buf.mirrorTo(mirror)
+
+	written := make(chan struct{})
+	go func() {
+		_, _ = buf.writer(stdoutStream).Write([]byte("hello"))
+		close(written)
+	}()
+
+	// Whilst the mirror is being written, the output can be read.
+	<-mirror.writing
+	got, err := io.ReadAll(buf.NewReader(true))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+
+	close(mirror.unblock)
+	<-written
+	buf.Close()
+	assert.Equal(t, "hello", mirror.String())
+}
@@ -0,0 +1,147 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// ConcurrencyKind is the kind of attribute of a task from which a concurrency
+// key is derived.
+type ConcurrencyKind string
+
+const (
+	// BackendConcurrency derives a key from the type of backend of the task's
+	// module, e.g. s3.
+	BackendConcurrency ConcurrencyKind = "backend"
+	// PathConcurrency derives a key from the path of the task's module.
+	PathConcurrency ConcurrencyKind = "path"
+	// EnvConcurrency derives a key from the value of an environment variable,
+	// e.g. AWS_PROFILE.
+	EnvConcurrency ConcurrencyKind = "env"
+)
+
+// ConcurrencyLimit limits the number of running tasks that share a
+// concurrency key.
+type ConcurrencyLimit struct {
+	Kind ConcurrencyKind
+	// Pattern is a glob matching the backend type or module path, or the name
+	// of an environment variable.
+	Pattern string
+	// Limit is the maximum number of running tasks sharing the key.
+	Limit int
+}
+
+// ConcurrencyLimits are limits in addition to the global maximum number of
+// running tasks.
+type ConcurrencyLimits []ConcurrencyLimit
+
+// ParseConcurrencyLimits parses limits, each of the form <kind>:<pattern>=<limit>,
+// e.g. backend:s3=5, path:prod/**=2, or env:AWS_PROFILE=3.
+func ParseConcurrencyLimits(values []string) (ConcurrencyLimits, error) {
+	var limits ConcurrencyLimits
+	for _, v := range values {
+		key, n, found := strings.Cut(v, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid concurrency limit: %s: missing limit", v)
+		}
+		kind, pattern, found := strings.Cut(key, ":")
+		if !found || pattern == "" {
+			return nil, fmt.Errorf("invalid concurrency limit: %s: missing pattern", v)
+		}
+		switch ConcurrencyKind(kind) {
+		case BackendConcurrency, PathConcurrency:
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid concurrency limit: %s: %w", v, err)
+			}
+		case EnvConcurrency:
+		default:
+			return nil, fmt.Errorf("invalid concurrency limit: %s: unknown kind: %s", v, kind)
+		}
+		limit, err := strconv.Atoi(n)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid concurrency limit: %s: limit must be a positive integer", v)
+		}
+		limits = append(limits, ConcurrencyLimit{
+			Kind:    ConcurrencyKind(kind),
+			Pattern: pattern,
+			Limit:   limit,
+		})
+	}
+	return limits, nil
+}
+
+// keys returns the concurrency keys of the task, mapped to their limits.
+// Tasks with a backend or path matching a limit's pattern share a key, whereas
+// tasks with an environment variable share a key only if the variable has the
+// same value. The backend function retrieves the backend type of a module.
+func (l ConcurrencyLimits) keys(t *Task, backend func(moduleID resource.ID) string) map[string]int {
+	keys := make(map[string]int)
+	for _, limit := range l {
+		var key string
+		switch limit.Kind {
+		case BackendConcurrency:
+			if t.ModuleID == nil || backend == nil {
+				continue
+			}
+			if ok, _ := path.Match(limit.Pattern, backend(*t.ModuleID)); !ok {
+				continue
+			}
+			key = fmt.Sprintf("%s:%s", limit.Kind, limit.Pattern)
+		case PathConcurrency:
+			if t.ModuleID == nil || !internal.MatchGlob(limit.Pattern, t.Spec.Path) {
+				continue
+			}
+			key = fmt.Sprintf("%s:%s", limit.Kind, limit.Pattern)
+		case EnvConcurrency:
+			value := t.env(limit.Pattern)
+			if value == "" {
+				continue
+			}
+			key = fmt.Sprintf("%s:%s=%s", limit.Kind, limit.Pattern, value)
+		}
+		// Where more than one limit applies to the same key, the lowest limit
+		// wins.
+		if existing, ok := keys[key]; !ok || limit.Limit < existing {
+			keys[key] = limit.Limit
+		}
+	}
+	return keys
+}
+
+// env returns the value of the named environment variable for the task's
+// process. The variable is inherited from pug's environment, and failing that
+// it is the variable set for the task, mirroring the environment with which
+// the process is started.
+func (t *Task) env(name string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	for i := len(t.AdditionalEnv) - 1; i >= 0; i-- {
+		if k, v, _ := strings.Cut(t.AdditionalEnv[i], "="); k == name {
+			return v
+		}
+	}
+	return ""
+}
+
+// workspaceName returns the name of the workspace the task belongs to, which
+// is set for the task as TF_WORKSPACE. Unlike env, it disregards pug's own
+// environment, which may well be set to some other workspace. Empty if the
+// task does not belong to a workspace.
+func (t *Task) workspaceName() string {
+	if t.WorkspaceID == nil {
+		return ""
+	}
+	for i := len(t.Spec.Env) - 1; i >= 0; i-- {
+		if k, v, _ := strings.Cut(t.Spec.Env[i], "="); k == "TF_WORKSPACE" {
+			return v
+		}
+	}
+	return ""
+}
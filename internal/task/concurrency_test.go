@@ -0,0 +1,109 @@
+package task
+
+import (
+	"os"
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConcurrencyLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    ConcurrencyLimits
+		wantErr bool
+	}{
+		{
+			name:   "none",
+			values: nil,
+			want:   nil,
+		},
+		{
+			name:   "one of each kind",
+			values: []string{"backend:s3=5", "path:prod/**=2", "env:AWS_PROFILE=3"},
+			want: ConcurrencyLimits{
+				{Kind: BackendConcurrency, Pattern: "s3", Limit: 5},
+				{Kind: PathConcurrency, Pattern: "prod/**", Limit: 2},
+				{Kind: EnvConcurrency, Pattern: "AWS_PROFILE", Limit: 3},
+			},
+		},
+		{
+			name:    "missing limit",
+			values:  []string{"backend:s3"},
+			wantErr: true,
+		},
+		{
+			name:    "missing pattern",
+			values:  []string{"backend=5"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			values:  []string{"region:us-east-1=5"},
+			wantErr: true,
+		},
+		{
+			name:    "zero limit",
+			values:  []string{"backend:s3=0"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid glob",
+			values:  []string{"path:[=1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConcurrencyLimits(tt.values)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConcurrencyLimits_keys(t *testing.T) {
+	// Ensure the variable is taken from the task rather than the environment
+	// (t.Setenv restores the environment after the test).
+	t.Setenv("AWS_PROFILE", "")
+	os.Unsetenv("AWS_PROFILE")
+
+	modID := resource.NewID(resource.Module)
+	backend := func(resource.ID) string { return "s3" }
+
+	limits := ConcurrencyLimits{
+		{Kind: BackendConcurrency, Pattern: "s3", Limit: 5},
+		{Kind: BackendConcurrency, Pattern: "gcs", Limit: 5},
+		{Kind: PathConcurrency, Pattern: "prod/**", Limit: 2},
+		{Kind: PathConcurrency, Pattern: "dev/**", Limit: 2},
+		{Kind: EnvConcurrency, Pattern: "AWS_PROFILE", Limit: 3},
+		{Kind: EnvConcurrency, Pattern: "AWS_PROFILE", Limit: 1},
+		{Kind: EnvConcurrency, Pattern: "GOOGLE_CLOUD_PROJECT", Limit: 1},
+	}
+
+	t.Run("module task", func(t *testing.T) {
+		task := &Task{
+			ModuleID:      &modID,
+			AdditionalEnv: []string{"AWS_PROFILE=staging", "AWS_PROFILE=prod"},
+			Spec:          Spec{Path: "prod/vpc"},
+		}
+		want := map[string]int{
+			"backend:s3":           5,
+			"path:prod/**":         2,
+			"env:AWS_PROFILE=prod": 1,
+		}
+		assert.Equal(t, want, limits.keys(task, backend))
+	})
+
+	t.Run("task without module", func(t *testing.T) {
+		task := &Task{Spec: Spec{Path: "prod/vpc"}}
+		assert.Empty(t, limits.keys(task, backend))
+	})
+}
@@ -0,0 +1,263 @@
+package task
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is an error or warning reported by terraform, e.g. an invalid
+// reference in the configuration.
+type Diagnostic struct {
+	// A diagnostic is a pug resource, but only insofar as it makes it easier
+	// to handle consistently alongside all other resources in the TUI.
+	resource.ID
+
+	// TaskID is the ID of the task that reported the diagnostic.
+	TaskID      resource.ID
+	ModuleID    *resource.ID
+	WorkspaceID *resource.ID
+
+	Severity Severity
+	Summary  string
+	Detail   string
+	// Filename is the name of the file to which the diagnostic refers,
+	// relative to the module, as reported by terraform. Empty if the
+	// diagnostic does not refer to a file.
+	Filename string
+	// Path is the absolute path to the file.
+	Path string
+	// Line is the line number within the file, starting at 1. Zero if the
+	// diagnostic does not refer to a line.
+	Line int
+}
+
+func (d Diagnostic) String() string {
+	return d.Summary
+}
+
+var (
+	// diagnosticHeader matches the first line of a diagnostic, e.g.:
+	//
+	//	Error: Invalid reference
+	diagnosticHeader = regexp.MustCompile(`^(Error|Warning): (.*)$`)
+	// diagnosticLocation matches the location of a diagnostic, e.g.:
+	//
+	//	on main.tf line 12, in resource "random_pet" "pet":
+	diagnosticLocation = regexp.MustCompile(`^on (.+) line (\d+)`)
+)
+
+// parseDiagnostics parses the diagnostics from the human-readable output of a
+// terraform command, which are usually drawn inside a box, e.g.:
+//
+//	╷
+//	│ Error: Reference to undeclared resource
+//	│
+//	│   on main.tf line 12, in resource "random_pet" "pet":
+//	│   12:   prefix = random_string.missing.result
+//	│
+//	│ A managed resource "random_string" "missing" has not been declared in
+//	│ the root module.
+//	╵
+func parseDiagnostics(r io.Reader) []Diagnostic {
+	// section is the part of the diagnostic being parsed.
+	type section int
+	const (
+		header section = iota
+		location
+		detail
+	)
+	var (
+		diags   []Diagnostic
+		current *Diagnostic
+		part    section
+		lines   []string
+		scanner = bufio.NewScanner(r)
+	)
+	finish := func() {
+		if current == nil {
+			return
+		}
+		current.Detail = strings.TrimSpace(strings.Join(lines, "\n"))
+		diags = append(diags, *current)
+		current = nil
+		lines = nil
+	}
+	for scanner.Scan() {
+		line := internal.StripAnsi(scanner.Text())
+		if strings.HasPrefix(strings.TrimSpace(line), "╵") {
+			// End of box
+			finish()
+			continue
+		}
+		// Strip the box drawing characters with which terraform surrounds
+		// diagnostics.
+		line = strings.TrimSpace(strings.TrimLeft(line, "│╷ "))
+		if matches := diagnosticHeader.FindStringSubmatch(line); matches != nil {
+			finish()
+			current = &Diagnostic{
+				Severity: Severity(strings.ToLower(matches[1])),
+				Summary:  strings.TrimSpace(matches[2]),
+			}
+			part = header
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch part {
+		case header:
+			if line == "" {
+				continue
+			}
+			if matches := diagnosticLocation.FindStringSubmatch(line); matches != nil {
+				current.Filename = matches[1]
+				current.Line, _ = strconv.Atoi(matches[2])
+				part = location
+				continue
+			}
+			part = detail
+			lines = append(lines, line)
+		case location:
+			// Skip the source code snippet that follows the location, which
+			// ends with a blank line.
+			if line == "" {
+				part = detail
+			}
+		case detail:
+			lines = append(lines, line)
+		}
+	}
+	finish()
+	return diags
+}
+
+// parseJSONDiagnostics parses the diagnostics from the JSON output of
+// `terraform validate -json`.
+func parseJSONDiagnostics(r io.Reader) ([]Diagnostic, error) {
+	var output struct {
+		Diagnostics []struct {
+			Severity Severity `json:"severity"`
+			Summary  string   `json:"summary"`
+			Detail   string   `json:"detail"`
+			Range    *struct {
+				Filename string `json:"filename"`
+				Start    struct {
+					Line int `json:"line"`
+				} `json:"start"`
+			} `json:"range"`
+		} `json:"diagnostics"`
+	}
+	if err := json.NewDecoder(r).Decode(&output); err != nil {
+		return nil, err
+	}
+	diags := make([]Diagnostic, len(output.Diagnostics))
+	for i, d := range output.Diagnostics {
+		diags[i] = Diagnostic{
+			Severity: d.Severity,
+			Summary:  d.Summary,
+			Detail:   d.Detail,
+		}
+		if d.Range != nil {
+			diags[i].Filename = d.Range.Filename
+			diags[i].Line = d.Range.Start.Line
+		}
+	}
+	return diags, nil
+}
+
+// setDiagnostics parses the diagnostics from the task's output and attaches
+// them to the task.
+func (t *Task) setDiagnostics() {
+	var diags []Diagnostic
+	if t.JSON {
+		var err error
+		diags, err = parseJSONDiagnostics(t.output.NewReader(false))
+		if err != nil {
+			// Terraform reports some errors, e.g. an invalid flag, without
+			// JSON.
+			diags = parseDiagnostics(t.output.NewReader(true))
+		}
+	} else {
+		diags = parseDiagnostics(t.output.NewReader(true))
+	}
+	for i := range diags {
+		diags[i].ID = resource.NewID(resource.Diagnostic)
+		diags[i].TaskID = t.ID
+		diags[i].ModuleID = t.ModuleID
+		diags[i].WorkspaceID = t.WorkspaceID
+		if diags[i].Filename != "" {
+			diags[i].Path = filepath.Join(t.Path, diags[i].Filename)
+		}
+	}
+	t.Diagnostics = diags
+}
+
+// Diagnostics returns the diagnostics reported by the most recently finished
+// task for each module and workspace, of those tasks that report diagnostics.
+func (s *Service) Diagnostics() []Diagnostic {
+	type key struct {
+		module, workspace resource.ID
+	}
+	var (
+		latest = make(map[key]*Task)
+		order  []key
+	)
+	for _, t := range s.tasks.List() {
+		if !t.Spec.Diagnostics || (t.State != Exited && t.State != Errored) || t.ModuleID == nil {
+			continue
+		}
+		k := key{module: *t.ModuleID}
+		if t.WorkspaceID != nil {
+			k.workspace = *t.WorkspaceID
+		}
+		existing, ok := latest[k]
+		if !ok {
+			order = append(order, k)
+		} else if existing.Updated.After(t.Updated) {
+			continue
+		}
+		latest[k] = t
+	}
+	var diags []Diagnostic
+	for _, k := range order {
+		diags = append(diags, latest[k].Diagnostics...)
+	}
+	return diags
+}
+
+// SortDiagnostics sorts diagnostics by severity, with errors first, and then
+// by file and line.
+func SortDiagnostics(i, j Diagnostic) int {
+	if i.Severity != j.Severity {
+		if i.Severity == SeverityError {
+			return -1
+		}
+		if j.Severity == SeverityError {
+			return 1
+		}
+	}
+	if c := strings.Compare(i.Path, j.Path); c != 0 {
+		return c
+	}
+	if i.Line != j.Line {
+		return i.Line - j.Line
+	}
+	return cmp.Compare(i.ID.Serial, j.ID.Serial)
+}
@@ -0,0 +1,103 @@
+package task
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	f, err := os.Open("./testdata/diagnostics.out")
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	want := []Diagnostic{
+		{
+			Severity: SeverityWarning,
+			Summary:  "Deprecated attribute",
+			Detail:   `The attribute "keepers" is deprecated.`,
+			Filename: "outputs.tf",
+			Line:     3,
+		},
+		{
+			Severity: SeverityError,
+			Summary:  "Reference to undeclared resource",
+			Detail:   "A managed resource \"random_string\" \"missing\" has not been declared in\nthe root module.",
+			Filename: "main.tf",
+			Line:     12,
+		},
+	}
+	assert.Equal(t, want, parseDiagnostics(f))
+}
+
+func TestParseDiagnostics_NoLocation(t *testing.T) {
+	f, err := os.Open("./testdata/validate.out")
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	got := parseDiagnostics(f)
+	require.Len(t, got, 1)
+	assert.Equal(t, SeverityError, got[0].Severity)
+	assert.Equal(t, "Could not load plugin", got[0].Summary)
+	assert.Contains(t, got[0].Detail, `Please run "terraform init".`)
+	assert.Empty(t, got[0].Filename)
+	assert.Zero(t, got[0].Line)
+}
+
+func TestParseJSONDiagnostics(t *testing.T) {
+	f, err := os.Open("./testdata/validate.json")
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	got, err := parseJSONDiagnostics(f)
+	require.NoError(t, err)
+
+	want := []Diagnostic{
+		{
+			Severity: SeverityError,
+			Summary:  "Unsupported argument",
+			Detail:   `An argument named "foo" is not expected here.`,
+			Filename: "main.tf",
+			Line:     4,
+		},
+		{
+			Severity: SeverityError,
+			Summary:  "Module not installed",
+			Detail:   `This module is not yet installed. Run "terraform init" to install all modules required by this configuration.`,
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestTask_Diagnostics(t *testing.T) {
+	f := factory{
+		publisher: &fakePublisher[*Task]{},
+	}
+	moduleID := resource.NewID(resource.Module)
+	task, err := f.newTask(Spec{
+		ModuleID: &moduleID,
+		Path:     "./testdata",
+		Execution: Execution{
+			Program: "cat",
+			Args:    []string{"diagnostics.out"},
+		},
+		Diagnostics: true,
+	})
+	require.NoError(t, err)
+	task.updateState(Queued)
+
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	require.Len(t, task.Diagnostics, 2)
+	got := task.Diagnostics[1]
+	assert.Equal(t, task.ID, got.TaskID)
+	assert.Equal(t, &moduleID, got.ModuleID)
+	assert.Equal(t, "testdata/main.tf", got.Path)
+	assert.Equal(t, 12, got.Line)
+}
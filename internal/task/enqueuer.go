@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"time"
 
 	"github.com/leg100/pug/internal/resource"
 )
@@ -14,6 +15,8 @@ import (
 // (c) if it belongs to a module then no other task has "blocked" that module
 // (d) if it has dependencies on other tasks then those tasks have all finished
 // successfully.
+// (e) if it retries a failed task then its backoff delay has elapsed.
+// (f) it is not held.
 //
 // Otherwise the enqueuer leaves the task in a pending state.
 type enqueuer struct {
@@ -65,14 +68,25 @@ func (e *enqueuer) enqueuable() []*Task {
 			}
 		}
 	}
-	// Retrieve pending tasks in order of oldest first.
+	// Retrieve pending tasks in order of highest priority first, and then
+	// oldest first.
 	pending := e.tasks.List(ListOptions{
 		Status: []Status{Pending},
 		Oldest: true,
 	})
+	sortByPriority(pending)
 	// Build list of tasks to enqueue
 	var enqueue []*Task
+	now := time.Now()
 	for _, t := range pending {
+		if t.held() {
+			// Don't enqueue task until it has been released.
+			continue
+		}
+		if now.Before(t.retryAt) {
+			// Don't enqueue retry until its backoff delay has elapsed.
+			continue
+		}
 		if t.Immediate {
 			// Always enqueue immediate tasks.
 			enqueue = append(enqueue, t)
@@ -116,19 +130,23 @@ func (e *enqueuer) enqueuable() []*Task {
 
 func (e *enqueuer) enqueueDependentTask(t *Task) bool {
 	for _, id := range t.DependsOn {
-		dependency, err := e.tasks.Get(id)
+		dependency, err := e.latestAttempt(id)
 		if err != nil {
 			// TODO: decide what to do in case of error
 			return false
 		}
-		switch dependency.State {
+		switch dependency.status() {
 		case Exited:
 			// Is enqueuable if all dependencies have exited successfully.
 		case Canceled, Errored:
+			if dependency.retrying() {
+				// Dependency is yet to be retried.
+				return false
+			}
 			// Dependency failed so mark task as failed too by cancelling it
 			// along with a reason why it was canceled.
-			t.stdout.Write([]byte("task dependency failed"))
-			t.updateState(Canceled)
+			t.output.writer(stdoutStream).Write([]byte("task dependency failed"))
+			t.cancel()
 			return false
 		default:
 			// Not enqueueable
@@ -137,3 +155,18 @@ func (e *enqueuer) enqueueDependentTask(t *Task) bool {
 	}
 	return true
 }
+
+// latestAttempt retrieves the task with the given ID, or if the task has been
+// retried, its most recent retry.
+func (e *enqueuer) latestAttempt(taskID resource.ID) (*Task, error) {
+	for {
+		t, err := e.tasks.Get(taskID)
+		if err != nil {
+			return nil, err
+		}
+		if t.RetriedBy == nil {
+			return t, nil
+		}
+		taskID = *t.RetriedBy
+	}
+}
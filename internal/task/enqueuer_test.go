@@ -3,8 +3,8 @@ package task
 import (
 	"slices"
 	"testing"
+	"time"
 
-	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,6 +25,7 @@ func TestEnqueuer(t *testing.T) {
 	ws1TaskBlocking1 := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Blocking: true})
 	ws1TaskBlocking2 := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Blocking: true})
 	ws1TaskBlocking3 := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Blocking: true})
+	ws1TaskBlockingHigh := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Blocking: true, Priority: HighPriority})
 	ws1TaskImmediate := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Immediate: true})
 	ws1TaskDependOnTask1 := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, dependsOn: []resource.ID{ws1Task1.ID}})
 
@@ -33,6 +34,25 @@ func TestEnqueuer(t *testing.T) {
 
 	ws1TaskDependOnCompletedTask := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, dependsOn: []resource.ID{ws1TaskCompleted.ID}})
 
+	ws1TaskRetrying := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID})
+	ws1TaskRetrying.updateState(Errored)
+	ws1TaskRetrying.Retrying = true
+
+	ws1TaskDependOnRetryingTask := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, dependsOn: []resource.ID{ws1TaskRetrying.ID}})
+
+	ws1TaskRetry := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID})
+	ws1TaskRetry.updateState(Exited)
+	ws1TaskRetried := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID})
+	ws1TaskRetried.updateState(Errored)
+	ws1TaskRetried.RetriedBy = &ws1TaskRetry.ID
+
+	ws1TaskDependOnRetriedTask := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, dependsOn: []resource.ID{ws1TaskRetried.ID}})
+
+	ws1TaskRetryAwaitingBackoff := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, attempt: 2, Retry: RetryPolicy{MaxAttempts: 2, Backoff: time.Hour}})
+
+	ws1TaskHeld := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Held: true})
+	ws1TaskImmediateHeld := newTestTask(t, Spec{ModuleID: &mod1ID, WorkspaceID: &ws1ID, Immediate: true, Held: true})
+
 	tests := []struct {
 		name string
 		// Active tasks
@@ -104,6 +124,33 @@ func TestEnqueuer(t *testing.T) {
 			pending: []*Task{ws1TaskDependOnCompletedTask},
 			want:    []*Task{ws1TaskDependOnCompletedTask},
 		},
+		{
+			name:    "enqueue higher priority blocking task ahead of older blocking task",
+			pending: []*Task{ws1TaskBlocking1, ws1TaskBlockingHigh},
+			want:    []*Task{ws1TaskBlockingHigh},
+		},
+		{
+			name:    "do not enqueue task with a dependency on a failed task awaiting retry",
+			other:   []*Task{ws1TaskRetrying},
+			pending: []*Task{ws1TaskDependOnRetryingTask},
+			want:    nil,
+		},
+		{
+			name:    "enqueue task with a dependency on a failed task that was successfully retried",
+			other:   []*Task{ws1TaskRetried, ws1TaskRetry},
+			pending: []*Task{ws1TaskDependOnRetriedTask},
+			want:    []*Task{ws1TaskDependOnRetriedTask},
+		},
+		{
+			name:    "do not enqueue retry before its backoff delay has elapsed",
+			pending: []*Task{ws1TaskRetryAwaitingBackoff},
+			want:    nil,
+		},
+		{
+			name:    "do not enqueue held tasks",
+			pending: []*Task{ws1TaskHeld, ws1TaskImmediateHeld},
+			want:    nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -120,7 +167,7 @@ func TestEnqueuer(t *testing.T) {
 }
 
 func newTestTask(t *testing.T, spec Spec) *Task {
-	f := &factory{counter: internal.Int(0)}
+	f := &factory{}
 	task, err := f.newTask(spec)
 	require.NoError(t, err)
 	return task
@@ -0,0 +1,171 @@
+package task
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// outputTimeFormat is the format of the timestamp in the name of a file to
+// which a task's output is written.
+const outputTimeFormat = "20060102T150405.000"
+
+// outputName returns the name of the file to which the task's output is
+// written, relative to the directory containing the file, in the form:
+//
+//	<module>/<workspace>/<timestamp>-<command>.log
+//
+// The module and workspace are omitted if the task does not belong to a module
+// or workspace.
+func (t *Task) outputName() string {
+	var parts []string
+	if t.ModuleID != nil {
+		parts = append(parts, t.Spec.Path)
+	}
+	if t.WorkspaceID != nil {
+		parts = append(parts, t.workspaceName())
+	}
+	// Replace characters in the command that are troublesome in file names,
+	// e.g. the spaces in "plan (destroy)".
+	command := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, t.String())
+	command = strings.Trim(command, "-")
+	parts = append(parts, fmt.Sprintf("%s-%s.log", t.Created.Format(outputTimeFormat), command))
+	return filepath.Join(parts...)
+}
+
+// mirrorOutput mirrors the task's output to a file in the directory as the
+// output is written.
+func (t *Task) mirrorOutput(dir string) error {
+	path := filepath.Join(dir, t.outputName())
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	t.output.mirrorTo(f)
+	return nil
+}
+
+// Export writes the combined output of the tasks, with ANSI escape codes
+// stripped, to dst. If dst ends with .tar.gz or .tgz then the output is
+// written to a gzipped tarball, or if it ends with .tar then to a tarball;
+// otherwise the output is written to files in the directory dst. Either way,
+// each task's output is written to a file named
+// <module>/<workspace>/<timestamp>-<command>.log.
+func (s *Service) Export(dst string, taskIDs ...resource.ID) error {
+	if len(taskIDs) == 0 {
+		return errors.New("no tasks to export")
+	}
+	tasks := make([]*Task, len(taskIDs))
+	for i, id := range taskIDs {
+		t, err := s.Get(id)
+		if err != nil {
+			return err
+		}
+		tasks[i] = t
+	}
+	switch {
+	case strings.HasSuffix(dst, ".tar.gz"), strings.HasSuffix(dst, ".tgz"):
+		return exportTarball(dst, true, tasks)
+	case strings.HasSuffix(dst, ".tar"):
+		return exportTarball(dst, false, tasks)
+	default:
+		return exportDir(dst, tasks)
+	}
+}
+
+// strippedOutput returns the combined output of the task with ANSI escape
+// codes stripped.
+func (t *Task) strippedOutput() ([]byte, error) {
+	out, err := io.ReadAll(t.NewReader(true))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(internal.StripAnsi(string(out))), nil
+}
+
+func exportDir(dir string, tasks []*Task) error {
+	for _, t := range tasks {
+		out, err := t.strippedOutput()
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, t.outputName())
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportTarball(path string, compress bool, tasks []*Task) (err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	var w io.Writer = f
+	if compress {
+		gw := gzip.NewWriter(f)
+		defer func() {
+			if closeErr := gw.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	defer func() {
+		if closeErr := tw.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	for _, t := range tasks {
+		out, err := t.strippedOutput()
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(t.outputName()),
+			Mode:    0o644,
+			Size:    int64(len(out)),
+			ModTime: t.Updated,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package task
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTask_outputName(t *testing.T) {
+	moduleID := resource.NewID(resource.Module)
+	workspaceID := resource.NewID(resource.Workspace)
+	created := time.Date(2024, 6, 1, 9, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		task *Task
+		want string
+	}{
+		{
+			"workspace task",
+			&Task{
+				ModuleID:    &moduleID,
+				WorkspaceID: &workspaceID,
+				Spec:        Spec{Path: "modules/a", Env: []string{"TF_WORKSPACE=dev"}},
+				Description: "plan (destroy)",
+				Created:     created,
+			},
+			"modules/a/dev/20240601T093015.000-plan--destroy.log",
+		},
+		{
+			"module task",
+			&Task{
+				ModuleID:    &moduleID,
+				Spec:        Spec{Path: "modules/a"},
+				Description: "init",
+				Created:     created,
+			},
+			"modules/a/20240601T093015.000-init.log",
+		},
+		{
+			"global task",
+			&Task{Description: "cost", Created: created},
+			"20240601T093015.000-cost.log",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.task.outputName())
+		})
+	}
+}
+
+func TestTask_MirrorOutput(t *testing.T) {
+	dir := t.TempDir()
+	f := factory{
+		publisher: &fakePublisher[*Task]{},
+		mirrorDir: dir,
+	}
+	task, err := f.newTask(Spec{Execution: Execution{Program: "echo", Args: []string{"hello"}}})
+	require.NoError(t, err)
+	task.updateState(Queued)
+
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	got, err := os.ReadFile(filepath.Join(dir, task.outputName()))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(got))
+}
+
+func TestService_Export(t *testing.T) {
+	svc := NewService(ServiceOptions{Logger: logging.Discard})
+	task, err := svc.newTask(Spec{
+		Execution: Execution{Program: "printf", Args: []string{`\033[1mbold\033[0m`}},
+	})
+	require.NoError(t, err)
+	svc.tasks.Add(task.ID, task)
+	task.updateState(Queued)
+
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	t.Run("directory", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, svc.Export(dir, task.ID))
+
+		got, err := os.ReadFile(filepath.Join(dir, task.outputName()))
+		require.NoError(t, err)
+		assert.Equal(t, "bold", string(got))
+	})
+
+	t.Run("tarball", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "output.tar.gz")
+		require.NoError(t, svc.Export(path, task.ID))
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		gr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		tr := tar.NewReader(gr)
+
+		hdr, err := tr.Next()
+		require.NoError(t, err)
+		assert.Equal(t, task.outputName(), hdr.Name)
+		got, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		assert.Equal(t, "bold", string(got))
+
+		_, err = tr.Next()
+		assert.Equal(t, io.EOF, err)
+	})
+}
@@ -4,27 +4,59 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/leg100/pug/internal/resource"
 )
 
+// GroupPolicy determines what happens to the remaining tasks in a group once
+// one of its tasks has failed.
+type GroupPolicy string
+
+const (
+	// ContinueGroupPolicy leaves the remaining tasks to continue. Tasks that
+	// depend on the failed task are canceled. This is the default policy.
+	ContinueGroupPolicy GroupPolicy = "continue"
+	// FailFastGroupPolicy cancels all remaining tasks, including running
+	// tasks.
+	FailFastGroupPolicy GroupPolicy = "fail-fast"
+	// StopGroupPolicy stops further tasks from starting, canceling pending
+	// and queued tasks, but leaves running tasks to finish.
+	StopGroupPolicy GroupPolicy = "stop"
+)
+
+// GroupPolicies are the valid group policies.
+var GroupPolicies = []string{
+	string(ContinueGroupPolicy),
+	string(FailFastGroupPolicy),
+	string(StopGroupPolicy),
+}
+
 type Group struct {
 	resource.ID
 
 	Created      time.Time
 	Command      string
+	Policy       GroupPolicy
 	Tasks        []*Task
 	CreateErrors []error
+
+	// halted is true if a task in the group has failed and the group's policy
+	// forbids starting further tasks.
+	halted atomic.Bool
+	// mu guards Tasks, to which retries are added once the group is running.
+	mu sync.RWMutex
 }
 
-func newGroup(service *Service, specs ...Spec) (*Group, error) {
+func newGroup(service *Service, policy GroupPolicy, specs ...Spec) (*Group, error) {
 	if len(specs) == 0 {
 		return nil, errors.New("no specs provided")
 	}
-	g := &Group{
-		ID:      resource.NewID(resource.TaskGroup),
-		Created: time.Now(),
+	g, err := newEmptyGroup(policy)
+	if err != nil {
+		return nil, err
 	}
 	// Validate specifications. There are some settings that are incompatible
 	// with one another within a task group.
@@ -32,7 +64,11 @@ func newGroup(service *Service, specs ...Spec) (*Group, error) {
 		respectModuleDependencies *bool
 		inverseDependencyOrder    *bool
 	)
-	for _, spec := range specs {
+	// Assign tasks to the group, without altering the caller's specs.
+	specs = slices.Clone(specs)
+	for i, spec := range specs {
+		specs[i].TaskGroupID = &g.ID
+		specs[i].group = g
 		// All specs must specify Dependencies or not specify Dependencies.
 		deps := (spec.Dependencies != nil)
 		if respectModuleDependencies == nil {
@@ -84,6 +120,22 @@ func newGroup(service *Service, specs ...Spec) (*Group, error) {
 	return g, nil
 }
 
+// newEmptyGroup constructs a group with the given policy, without any tasks.
+func newEmptyGroup(policy GroupPolicy) (*Group, error) {
+	switch policy {
+	case "":
+		policy = ContinueGroupPolicy
+	case ContinueGroupPolicy, FailFastGroupPolicy, StopGroupPolicy:
+	default:
+		return nil, fmt.Errorf("invalid group policy: %s", policy)
+	}
+	return &Group{
+		ID:      resource.NewID(resource.TaskGroup),
+		Created: time.Now(),
+		Policy:  policy,
+	}, nil
+}
+
 func (g *Group) String() string { return g.Command }
 
 func (g *Group) IncludesTask(taskID resource.ID) bool {
@@ -92,10 +144,16 @@ func (g *Group) IncludesTask(taskID resource.ID) bool {
 	})
 }
 
+// Len returns the number of tasks in the group, excluding failed tasks that
+// have since been retried.
+func (g *Group) Len() int {
+	return len(g.latest())
+}
+
 func (g *Group) Finished() int {
 	var finished int
-	for _, t := range g.Tasks {
-		if t.State.IsFinal() {
+	for _, t := range g.latest() {
+		if t.status().IsFinal() {
 			finished++
 		}
 	}
@@ -104,8 +162,8 @@ func (g *Group) Finished() int {
 
 func (g *Group) Exited() int {
 	var exited int
-	for _, t := range g.Tasks {
-		if t.State == Exited {
+	for _, t := range g.latest() {
+		if t.status() == Exited {
 			exited++
 		}
 	}
@@ -114,14 +172,80 @@ func (g *Group) Exited() int {
 
 func (g *Group) Errored() int {
 	var errored int
-	for _, t := range g.Tasks {
-		if t.State == Errored {
+	for _, t := range g.latest() {
+		if t.status() == Errored {
 			errored++
 		}
 	}
 	return errored
 }
 
+// latest returns the group's tasks, skipping failed tasks that have since been
+// retried, leaving only the latest attempt of each task.
+func (g *Group) latest() []*Task {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	tasks := make([]*Task, 0, len(g.Tasks))
+	for _, t := range g.Tasks {
+		if t.retriedBy() == nil {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// add adds a task to the group.
+func (g *Group) add(t *Task) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.Tasks = append(g.Tasks, t)
+}
+
+// halt halts the group following the failure of one of its tasks, unless its
+// policy is to continue.
+func (g *Group) halt() {
+	if g.Policy == FailFastGroupPolicy || g.Policy == StopGroupPolicy {
+		g.halted.Store(true)
+	}
+}
+
+// Remaining returns the group's tasks that have yet to finish.
+func (g *Group) Remaining() []*Task {
+	var remaining []*Task
+	for _, t := range g.latest() {
+		if !t.status().IsFinal() {
+			remaining = append(remaining, t)
+		}
+	}
+	return remaining
+}
+
+// Held returns the group's tasks that are held back from being enqueued.
+func (g *Group) Held() []*Task {
+	var held []*Task
+	for _, t := range g.latest() {
+		if t.held() && t.status() == Pending {
+			held = append(held, t)
+		}
+	}
+	return held
+}
+
+// Failed returns the group's tasks that have errored or been canceled,
+// excluding errored tasks that are yet to be automatically retried.
+func (g *Group) Failed() []*Task {
+	var failed []*Task
+	for _, t := range g.latest() {
+		state := t.status()
+		if (state == Errored && !t.retrying()) || state == Canceled {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
 func SortGroupsByCreated(i, j *Group) int {
 	if i.Created.After(j.Created) {
 		return -1
@@ -0,0 +1,133 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_GroupPolicy(t *testing.T) {
+	// The failing task has a high priority to ensure it runs first.
+	failing := Spec{Execution: Execution{Program: "false"}, Priority: HighPriority}
+	succeeding := Spec{Execution: Execution{Program: "true"}}
+	sleeping := Spec{Execution: Execution{Program: "sleep", Args: []string{"10"}}}
+
+	tests := []struct {
+		name     string
+		policy   GroupPolicy
+		maxTasks int
+		specs    []Spec
+		want     []Status
+	}{
+		{
+			name:     "continue",
+			policy:   ContinueGroupPolicy,
+			maxTasks: 1,
+			specs:    []Spec{failing, succeeding, succeeding},
+			want:     []Status{Errored, Exited, Exited},
+		},
+		{
+			name:     "stop starting new tasks",
+			policy:   StopGroupPolicy,
+			maxTasks: 1,
+			specs:    []Spec{failing, succeeding, succeeding},
+			want:     []Status{Errored, Canceled, Canceled},
+		},
+		{
+			name:     "fail fast",
+			policy:   FailFastGroupPolicy,
+			maxTasks: 2,
+			specs:    []Spec{failing, sleeping, sleeping},
+			// The running task is interrupted, and the pending task canceled.
+			want: []Status{Errored, Errored, Canceled},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(ServiceOptions{Logger: logging.Discard})
+			// Pause the queue until the group is created, so that the failing
+			// task is guaranteed to be run first.
+			svc.Pause()
+			StartEnqueuer(svc)
+			StartRunner(context.Background(), logging.Discard, svc, RunnerOptions{MaxTasks: tt.maxTasks})
+
+			group, err := svc.CreateGroupWithPolicy(tt.policy, tt.specs...)
+			require.NoError(t, err)
+			svc.Resume()
+
+			for i, task := range group.Tasks {
+				select {
+				case <-task.finished:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for task %d to finish", i)
+				}
+				assert.Equal(t, tt.want[i], task.State, "task %d", i)
+			}
+		})
+	}
+}
+
+func TestService_RetryGroup(t *testing.T) {
+	svc := NewService(ServiceOptions{Logger: logging.Discard})
+	StartEnqueuer(svc)
+	StartRunner(context.Background(), logging.Discard, svc, RunnerOptions{MaxTasks: 1})
+
+	group, err := svc.CreateGroupWithPolicy(StopGroupPolicy,
+		Spec{Execution: Execution{Program: "false"}, Priority: HighPriority},
+		Spec{Execution: Execution{Program: "true"}},
+	)
+	require.NoError(t, err)
+	for _, task := range group.Tasks {
+		<-task.finished
+	}
+
+	retry, err := svc.RetryGroup(group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StopGroupPolicy, retry.Policy)
+	require.Len(t, retry.Tasks, 2)
+	for _, task := range retry.Tasks {
+		assert.Equal(t, retry.ID, *task.TaskGroupID)
+	}
+}
+
+func TestService_ReleaseGroup(t *testing.T) {
+	svc := NewService(ServiceOptions{Logger: logging.Discard})
+	StartEnqueuer(svc)
+	StartRunner(context.Background(), logging.Discard, svc, RunnerOptions{MaxTasks: 1})
+
+	group, err := svc.CreateGroup(
+		Spec{Execution: Execution{Program: "true"}, Held: true},
+		Spec{Execution: Execution{Program: "true"}, Held: true},
+	)
+	require.NoError(t, err)
+	require.Len(t, group.Held(), 2)
+
+	// Trigger the enqueuer, which should leave the held tasks pending.
+	svc.tasks.Update(group.Tasks[0].ID, func(*Task) error { return nil })
+	time.Sleep(100 * time.Millisecond)
+	for _, task := range group.Tasks {
+		assert.Equal(t, Pending, task.State)
+	}
+
+	released, err := svc.ReleaseGroup(group.ID)
+	require.NoError(t, err)
+	assert.Len(t, released, 2)
+	for i, task := range group.Tasks {
+		select {
+		case <-task.finished:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for task %d to finish", i)
+		}
+		assert.Equal(t, Exited, task.State)
+		// A manual retry of the task is not held.
+		assert.False(t, task.Spec.Held)
+	}
+	assert.Empty(t, group.Held())
+
+	_, err = svc.ReleaseGroup(group.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/leg100/pug/internal"
+)
+
+// Hooks are programs run before and after types of task.
+type Hooks struct {
+	// Pre maps task identifiers to programs run before the task's program. If
+	// any program fails then the task's program is not run and the task is
+	// errored.
+	Pre map[Identifier][]Execution
+	// Post maps task identifiers to programs run after the task finishes,
+	// regardless of whether the task succeeded.
+	Post map[Identifier][]Execution
+}
+
+// ParseHooks parses pre- and post-task hooks, each of the form
+// <identifier>=<command>, e.g. plan=tflint or apply=./notify.sh. The
+// identifier must be one of the known identifiers. The command is split into
+// words in the manner of a shell, so args containing spaces can be quoted, e.g.
+// plan=tflint --config "a b.hcl".
+func ParseHooks(pre, post []string, known []Identifier) (Hooks, error) {
+	var hooks Hooks
+	for _, phase := range []struct {
+		values []string
+		hooks  *map[Identifier][]Execution
+	}{
+		{pre, &hooks.Pre},
+		{post, &hooks.Post},
+	} {
+		for _, v := range phase.values {
+			id, command, found := strings.Cut(v, "=")
+			if !found || id == "" {
+				return Hooks{}, fmt.Errorf("invalid hook: %s: missing task identifier", v)
+			}
+			if err := validateIdentifier(Identifier(id), known); err != nil {
+				return Hooks{}, fmt.Errorf("invalid hook: %s: %w", v, err)
+			}
+			fields, err := internal.SplitWords(command)
+			if err != nil {
+				return Hooks{}, fmt.Errorf("invalid hook: %s: %w", v, err)
+			}
+			if len(fields) == 0 {
+				return Hooks{}, fmt.Errorf("invalid hook: %s: missing command", v)
+			}
+			if *phase.hooks == nil {
+				*phase.hooks = make(map[Identifier][]Execution)
+			}
+			(*phase.hooks)[Identifier(id)] = append((*phase.hooks)[Identifier(id)], Execution{
+				Program: fields[0],
+				Args:    fields[1:],
+			})
+		}
+	}
+	return hooks, nil
+}
+
+func (e Execution) String() string {
+	return internal.JoinWords(append([]string{e.Program}, e.Args...))
+}
+
+// hookEnv returns the environment variables with which hooks are run,
+// describing the task, and its status, to the hook.
+func (t *Task) hookEnv(status Status) []string {
+	env := []string{
+		"PUG_TASK=" + string(t.Spec.identifier()),
+		"PUG_TASK_STATUS=" + string(status),
+		"PUG_MODULE_PATH=" + t.Spec.Path,
+	}
+	if t.WorkspaceID != nil {
+		env = append(env, "PUG_WORKSPACE="+t.workspaceName())
+	}
+	if t.Summary != nil {
+		// For a plan or apply this is the report, e.g. +1~0-0.
+		env = append(env, "PUG_TASK_SUMMARY="+t.Summary.String())
+	}
+	return env
+}
+
+// executeHook returns a command to run a hook in the task's module directory.
+// The hook's output is written to the stderr stream, so that it is not
+// mistaken for the output of the task's program.
+func (t *Task) executeHook(ctx context.Context, hook Execution, status Status) *exec.Cmd {
+	cmd := t.execute(ctx, hook.Program, hook.Args)
+	cmd.Stdout = t.output.writer(stderrStream)
+	cmd.Env = append(cmd.Env, t.hookEnv(status)...)
+	return cmd
+}
+
+// runPostHooks runs the task's post-task hooks in turn, once the task has
+// finished. Their output is returned, because the task's output streams are
+// closed by the time they run. The output and any error are also recorded on
+// the task, so that they can be shown along with the task.
+func (t *Task) runPostHooks(ctx context.Context) ([]byte, error) {
+	out, err := func() ([]byte, error) {
+		var out []byte
+		for _, hook := range t.postHooks {
+			cmd := t.executeHook(ctx, hook, t.State)
+			cmd.Stdout, cmd.Stderr = nil, nil
+			output, err := cmd.CombinedOutput()
+			out = append(out, output...)
+			if err != nil {
+				return out, fmt.Errorf("post-hook failed: %s: %w", hook, err)
+			}
+		}
+		return out, nil
+	}()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.PostHookOutput = string(out)
+	t.PostHookErr = err
+	if t.afterUpdate != nil {
+		t.afterUpdate(t)
+	}
+	return out, err
+}
@@ -0,0 +1,174 @@
+package task
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		pre     []string
+		post    []string
+		want    Hooks
+		wantErr bool
+	}{
+		{"none", nil, nil, Hooks{}, false},
+		{
+			"pre and post",
+			[]string{"plan=tflint --format compact", "plan=checkov"},
+			[]string{"state rm=./notify.sh"},
+			Hooks{
+				Pre: map[Identifier][]Execution{
+					"plan": {
+						{Program: "tflint", Args: []string{"--format", "compact"}},
+						{Program: "checkov", Args: []string{}},
+					},
+				},
+				Post: map[Identifier][]Execution{
+					"state rm": {{Program: "./notify.sh", Args: []string{}}},
+				},
+			},
+			false,
+		},
+		{
+			"quoted args",
+			[]string{`plan=tflint --config "a b.hcl"`},
+			nil,
+			Hooks{
+				Pre: map[Identifier][]Execution{
+					"plan": {{Program: "tflint", Args: []string{"--config", "a b.hcl"}}},
+				},
+			},
+			false,
+		},
+		{"unterminated quote", []string{`plan=tflint --config "a b.hcl`}, nil, Hooks{}, true},
+		{"missing identifier", []string{"tflint"}, nil, Hooks{}, true},
+		{"missing command", nil, []string{"apply= "}, Hooks{}, true},
+		{"unknown identifier", nil, []string{"state-pull=./notify.sh"}, Hooks{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHooks(tt.pre, tt.post, []Identifier{"plan", "apply", "state rm"})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTask_hooks(t *testing.T) {
+	tests := []struct {
+		name string
+		pre  string
+		// wantState is the state of the task after it has finished.
+		wantState Status
+		// wantOutput is the combined output of the task.
+		wantOutput string
+		// wantPost is the output of the post-hook.
+		wantPost string
+	}{
+		{
+			"pre-hook succeeds",
+			"echo pre",
+			Exited,
+			"pre\nmain\n",
+			"test exited dev",
+		},
+		{
+			"pre-hook fails",
+			"echo pre; exit 1",
+			Errored,
+			"pre\n",
+			"test errored dev",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The hook is told the task's workspace, not that of pug's own
+			// environment.
+			t.Setenv("TF_WORKSPACE", "default")
+
+			f := factory{
+				publisher: &fakePublisher[*Task]{},
+				hooks: Hooks{
+					Pre: map[Identifier][]Execution{
+						"test": {{Program: "sh", Args: []string{"-c", tt.pre}}},
+					},
+					Post: map[Identifier][]Execution{
+						"test": {{Program: "sh", Args: []string{"-c", "echo -n $PUG_TASK $PUG_TASK_STATUS $PUG_WORKSPACE"}}},
+					},
+				},
+			}
+			moduleID := resource.NewID(resource.Module)
+			workspaceID := resource.NewID(resource.Workspace)
+			task, err := f.newTask(Spec{
+				ModuleID:    &moduleID,
+				WorkspaceID: &workspaceID,
+				Identifier:  "test",
+				Path:        t.TempDir(),
+				Env:         []string{"TF_WORKSPACE=dev"},
+				Execution:   Execution{Program: "echo", Args: []string{"main"}},
+			})
+			require.NoError(t, err)
+			task.updateState(Queued)
+
+			waitfn, err := task.start(context.Background())
+			require.NoError(t, err)
+			waitfn()
+
+			assert.Equal(t, tt.wantState, task.State)
+			combined, err := io.ReadAll(task.NewReader(true))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOutput, string(combined))
+			// The pre-hook's output is excluded from the program's output.
+			stdout, err := io.ReadAll(task.NewReader(false))
+			require.NoError(t, err)
+			assert.NotContains(t, string(stdout), "pre")
+
+			post, err := task.runPostHooks(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPost, string(post))
+			// The post-hook's output is recorded on the task.
+			assert.Equal(t, tt.wantPost, task.PostHookOutput)
+			assert.NoError(t, task.PostHookErr)
+		})
+	}
+}
+
+func TestTask_runPostHooks_Error(t *testing.T) {
+	f := factory{
+		publisher: &fakePublisher[*Task]{},
+		hooks: Hooks{
+			Post: map[Identifier][]Execution{
+				"test": {{Program: "sh", Args: []string{"-c", "echo oops; exit 1"}}},
+			},
+		},
+	}
+	task, err := f.newTask(Spec{
+		Identifier: "test",
+		Path:       t.TempDir(),
+		Execution:  Execution{Program: "true"},
+	})
+	require.NoError(t, err)
+	task.updateState(Queued)
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	_, err = task.runPostHooks(context.Background())
+	require.Error(t, err)
+
+	// The failure is recorded on the task.
+	assert.Equal(t, Exited, task.State)
+	assert.Equal(t, "oops\n", task.PostHookOutput)
+	assert.Equal(t, err, task.PostHookErr)
+}
@@ -0,0 +1,97 @@
+package task
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/leg100/pug/internal"
+)
+
+// LockInfo is information about a lock on terraform state, as reported by
+// terraform when it fails to acquire the lock.
+type LockInfo struct {
+	ID        string
+	Path      string
+	Operation string
+	Who       string
+	Version   string
+	Created   time.Time
+	Info      string
+}
+
+// LockError is the error attached to a task that failed because the state is
+// locked.
+type LockError struct {
+	LockInfo
+
+	err error
+}
+
+func (e *LockError) Error() string {
+	return fmt.Sprintf("state locked by %s for %s (lock ID: %s)", e.Who, e.Operation, e.ID)
+}
+
+func (e *LockError) Unwrap() error { return e.err }
+
+var lockInfoField = regexp.MustCompile(`^(ID|Path|Operation|Who|Version|Created|Info):\s*(.*)$`)
+
+// parseLockInfo parses the lock info from the output of a terraform command
+// that failed to acquire the state lock. False is returned if the output does
+// not report a lock error.
+func parseLockInfo(r io.Reader) (LockInfo, bool) {
+	var (
+		info     LockInfo
+		found    bool
+		scanner  = bufio.NewScanner(r)
+		lockInfo bool
+	)
+	for scanner.Scan() {
+		// Strip ANSI codes and the box drawing characters with which
+		// terraform surrounds diagnostics.
+		line := internal.StripAnsi(scanner.Text())
+		line = strings.TrimSpace(strings.TrimLeft(line, "│╷╵ "))
+		if !lockInfo {
+			if strings.Contains(line, "Error acquiring the state lock") {
+				found = true
+			}
+			if found && line == "Lock Info:" {
+				lockInfo = true
+			}
+			continue
+		}
+		matches := lockInfoField.FindStringSubmatch(line)
+		if matches == nil {
+			if line == "" {
+				continue
+			}
+			// Lock info has ended
+			break
+		}
+		switch value := strings.TrimSpace(matches[2]); matches[1] {
+		case "ID":
+			info.ID = value
+		case "Path":
+			info.Path = value
+		case "Operation":
+			info.Operation = value
+		case "Who":
+			info.Who = value
+		case "Version":
+			info.Version = value
+		case "Created":
+			// Terraform formats the time using the default Go format.
+			info.Created, _ = time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", value)
+		case "Info":
+			info.Info = value
+		}
+	}
+	// The lock ID is required in order to unlock the state.
+	if info.ID == "" {
+		return LockInfo{}, false
+	}
+	return info, true
+}
@@ -0,0 +1,59 @@
+package task
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLockInfo(t *testing.T) {
+	f, err := os.Open("./testdata/state_lock_error.out")
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	got, ok := parseLockInfo(f)
+	require.True(t, ok)
+
+	want := LockInfo{
+		ID:        "7f9fbbd5-43e5-1ff4-d2f0-a4e8e06b1ff0",
+		Path:      "terraform.tfstate",
+		Operation: "OperationTypeApply",
+		Who:       "louis@laptop",
+		Version:   "1.8.3",
+		Created:   time.Date(2024, 6, 1, 9, 30, 15, 123456789, time.UTC),
+	}
+	// Compare times separately because their locations differ.
+	assert.True(t, want.Created.Equal(got.Created))
+	got.Created = want.Created
+	assert.Equal(t, want, got)
+}
+
+func TestParseLockInfo_NoLock(t *testing.T) {
+	_, ok := parseLockInfo(strings.NewReader("Error: Invalid provider configuration\n"))
+	assert.False(t, ok)
+}
+
+func TestTask_LockError(t *testing.T) {
+	f := factory{
+		program:   "./testdata/locked",
+		publisher: &fakePublisher[*Task]{},
+	}
+	task, err := f.newTask(Spec{})
+	require.NoError(t, err)
+	task.updateState(Queued)
+
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	assert.Equal(t, Errored, task.State)
+	var lockErr *LockError
+	require.ErrorAs(t, task.Err, &lockErr)
+	assert.Equal(t, "7f9fbbd5-43e5-1ff4-d2f0-a4e8e06b1ff0", lockErr.ID)
+	assert.Equal(t, "louis@laptop", lockErr.Who)
+}
@@ -0,0 +1,30 @@
+package task
+
+// Pause pauses the task queue: no further tasks are started until the queue is
+// resumed, other than immediate tasks. Running tasks are unaffected and are left
+// to finish.
+func (s *Service) Pause() {
+	if s.paused.Swap(true) {
+		return
+	}
+	s.logger.Info("paused task queue")
+}
+
+// Resume resumes the task queue, starting any queued tasks that were held
+// whilst the queue was paused.
+func (s *Service) Resume() {
+	if !s.paused.Swap(false) {
+		return
+	}
+	s.logger.Info("resumed task queue")
+	// The runner is triggered by task events, so trigger an event on a queued
+	// task in order for the runner to start queued tasks.
+	if queued := s.List(ListOptions{Status: []Status{Queued}}); len(queued) > 0 {
+		s.tasks.Update(queued[0].ID, func(*Task) error { return nil })
+	}
+}
+
+// Paused returns true if the task queue is paused.
+func (s *Service) Paused() bool {
+	return s.paused.Load()
+}
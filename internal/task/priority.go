@@ -0,0 +1,92 @@
+package task
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Priority determines the order in which tasks are enqueued and run. Tasks
+// with a higher priority are processed before those with a lower priority,
+// and tasks with the same priority are processed oldest first.
+type Priority int
+
+const (
+	LowPriority    Priority = -1
+	NormalPriority Priority = 0
+	HighPriority   Priority = 1
+)
+
+// priority determines the priority of a task created from the spec: the
+// priority to which the user reprioritized a previous task created from the
+// spec, the spec's own priority, or, failing that, the default priority for the
+// type of task.
+func (f *factory) priority(spec Spec) Priority {
+	if spec.reprioritized != nil {
+		return *spec.reprioritized
+	}
+	if spec.Priority != NormalPriority {
+		return spec.Priority
+	}
+	return f.priorities[spec.identifier()]
+}
+
+func (p Priority) String() string {
+	switch p {
+	case LowPriority:
+		return "low"
+	case NormalPriority:
+		return "normal"
+	case HighPriority:
+		return "high"
+	default:
+		return fmt.Sprintf("%+d", p)
+	}
+}
+
+// sortByPriority sorts tasks by priority, highest first, retaining the
+// existing order of tasks with the same priority.
+func sortByPriority(tasks []*Task) {
+	slices.SortStableFunc(tasks, func(a, b *Task) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+}
+
+// Bump raises the priority of a pending or queued task, moving it ahead of
+// tasks with a lower priority.
+func (s *Service) Bump(taskID resource.ID) (*Task, error) {
+	return s.reprioritize(taskID, 1)
+}
+
+// Demote lowers the priority of a pending or queued task, moving it behind
+// tasks with a higher priority.
+func (s *Service) Demote(taskID resource.ID) (*Task, error) {
+	return s.reprioritize(taskID, -1)
+}
+
+func (s *Service) reprioritize(taskID resource.ID, delta Priority) (*Task, error) {
+	task, err := s.tasks.Update(taskID, func(existing *Task) error {
+		existing.mu.Lock()
+		defer existing.mu.Unlock()
+
+		switch existing.State {
+		case Pending, Queued:
+			existing.Priority += delta
+			// Retain the priority should the task be retried.
+			priority := existing.Priority
+			existing.Spec.reprioritized = &priority
+			return nil
+		default:
+			return errors.New("only pending and queued tasks can be reprioritized")
+		}
+	})
+	if err != nil {
+		s.logger.Error("reprioritizing task", "id", taskID, "error", err)
+		return nil, err
+	}
+	s.logger.Debug("reprioritized task", "task", task, "priority", task.Priority)
+	return task, nil
+}
@@ -0,0 +1,40 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory_priority(t *testing.T) {
+	f := &factory{
+		priorities: map[Identifier]Priority{
+			"state-reload":   HighPriority,
+			"workspace list": HighPriority,
+			"cost":           LowPriority,
+		},
+	}
+	normal := NormalPriority
+	tests := []struct {
+		name string
+		spec Spec
+		want Priority
+	}{
+		{"identifier", Spec{Identifier: "state-reload"}, HighPriority},
+		{"terraform command", Spec{Execution: Execution{TerraformCommand: []string{"workspace", "list"}}}, HighPriority},
+		{"low priority", Spec{Identifier: "cost"}, LowPriority},
+		{"spec overrides default", Spec{Identifier: "cost", Priority: HighPriority}, HighPriority},
+		{"no default", Spec{Identifier: "plan"}, NormalPriority},
+		{"reprioritized", Spec{Identifier: "cost", Priority: HighPriority, reprioritized: &normal}, NormalPriority},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, f.priority(tt.spec))
+
+			task, err := f.newTask(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, task.Priority)
+		})
+	}
+}
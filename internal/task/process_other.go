@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package task
+
+import "os"
+
+// processExists returns true if a process with the given ID is running.
+func processExists(pid int) bool {
+	// On Windows FindProcess fails if the process does not exist. Elsewhere it
+	// always succeeds, in which case the process is assumed to exist.
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package task
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// processExists returns true if a process with the given ID is running.
+func processExists(pid int) bool {
+	err := unix.Kill(pid, 0)
+	return err == nil || errors.Is(err, unix.EPERM)
+}
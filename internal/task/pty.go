@@ -0,0 +1,107 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const (
+	// ptyRows and ptyCols are the dimensions of the pseudo-terminal in which
+	// a task is run, those of a standard terminal.
+	ptyRows = 24
+	ptyCols = 80
+	// ptyDrainPeriod is how long to wait for the remaining output of a
+	// program once it has exited, before closing its pseudo-terminal.
+	ptyDrainPeriod = time.Second
+)
+
+// pty is the pseudo-terminal in which a task's program is running.
+type pty struct {
+	ptmx *os.File
+	// done is closed once the program's output has been copied.
+	done chan struct{}
+}
+
+// startCmd starts the command. If usePTY is true then the command is started
+// in a new pseudo-terminal, from which its output is copied to the task's
+// output streams, and to which input can be written with WriteInput. Both
+// stdout and stderr are written to the terminal, so the task's stdout stream
+// includes stderr too. The task lock must be held.
+func (t *Task) startCmd(cmd *exec.Cmd, usePTY bool) error {
+	if !usePTY {
+		return cmd.Start()
+	}
+	ptmx, tty, err := openPTY(ptyRows, ptyCols)
+	if err != nil {
+		return fmt.Errorf("opening pseudo-terminal: %w", err)
+	}
+	// The program is handed its own copy of the terminal, so close the
+	// original once the program has started; otherwise reading from the
+	// terminal wouldn't stop once the program exits.
+	defer tty.Close()
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	cmd.SysProcAttr = ptySysProcAttr()
+	if err := cmd.Start(); err != nil {
+		ptmx.Close()
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Reading returns an error once the terminal is closed by both the
+		// program and pug.
+		_, _ = io.Copy(t.output.writer(stdoutStream), ptmx)
+	}()
+	t.pty = &pty{ptmx: ptmx, done: done}
+	return nil
+}
+
+// waitCmd waits for the command to exit, and if it was started in a
+// pseudo-terminal, for its output to be copied.
+func (t *Task) waitCmd(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+
+	t.mu.Lock()
+	p := t.pty
+	t.pty = nil
+	t.mu.Unlock()
+
+	if p != nil {
+		// The program may have left behind a process that has yet to close the
+		// terminal, in which case stop waiting for its output.
+		select {
+		case <-p.done:
+		case <-time.After(ptyDrainPeriod):
+		}
+		p.ptmx.Close()
+		<-p.done
+	}
+	return err
+}
+
+// WriteInput writes input to the task's program, as if typed at a terminal.
+// Only a task running in a pseudo-terminal accepts input.
+func (t *Task) WriteInput(p []byte) error {
+	t.mu.Lock()
+	pty := t.pty
+	t.mu.Unlock()
+
+	if pty == nil {
+		return errors.New("task is not running in a pseudo-terminal")
+	}
+	_, err := pty.ptmx.Write(p)
+	return err
+}
+
+// Attachable returns true if input can be written to the task's program.
+func (t *Task) Attachable() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.pty != nil
+}
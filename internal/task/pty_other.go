@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package task
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func openPTY(rows, cols int) (ptmx, tty *os.File, err error) {
+	return nil, nil, errors.New("pseudo-terminals are not supported on this platform")
+}
+
+func ptySysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
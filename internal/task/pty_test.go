@@ -0,0 +1,55 @@
+//go:build linux || darwin
+
+package task
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTask_PTY(t *testing.T) {
+	f := factory{
+		publisher: &fakePublisher[*Task]{},
+		pty:       []string{"sh"},
+	}
+	task, err := f.newTask(Spec{
+		Execution: Execution{
+			Program: "sh",
+			Args:    []string{"-c", `[ -t 0 ] && read answer && echo "answer: $answer"`},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, task.PTY)
+	task.updateState(Queued)
+
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	require.True(t, task.Attachable())
+
+	require.NoError(t, task.WriteInput([]byte("yes\r")))
+
+	done := make(chan struct{})
+	go func() {
+		waitfn()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for task to finish")
+	}
+	assert.Equal(t, Exited, task.State)
+	assert.False(t, task.Attachable())
+
+	got, err := io.ReadAll(task.NewReader(false))
+	require.NoError(t, err)
+	// The input is echoed back by the terminal.
+	assert.Equal(t, "yes\nanswer: yes\n", string(got))
+
+	assert.Error(t, task.WriteInput([]byte("too late\r")))
+}
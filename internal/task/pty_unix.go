@@ -0,0 +1,62 @@
+//go:build linux || darwin
+
+package task
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a new pseudo-terminal with the given dimensions, returning
+// both its controlling side, and the terminal to be handed to a program.
+func openPTY(rows, cols int) (ptmx, tty *os.File, err error) {
+	ptmx, tty, err = openPTYPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = control(tty, func(fd int) error {
+		// Stop the terminal from translating newlines into carriage return
+		// and newline pairs, so that output is the same as without a terminal.
+		termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+		if err != nil {
+			return err
+		}
+		termios.Oflag &^= unix.ONLCR
+		if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+			return err
+		}
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{
+			Row: uint16(rows),
+			Col: uint16(cols),
+		})
+	})
+	if err != nil {
+		ptmx.Close()
+		tty.Close()
+		return nil, nil, err
+	}
+	return ptmx, tty, nil
+}
+
+// ptySysProcAttr starts a program in a new session, with the pseudo-terminal
+// on its stdin as its controlling terminal.
+func ptySysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true, Setctty: true}
+}
+
+// control calls fn with the file's descriptor, without switching the file
+// into blocking mode, which would stop reads from being interrupted when the
+// file is closed.
+func control(f *os.File, fn func(fd int) error) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var fnErr error
+	if err := conn.Control(func(fd uintptr) { fnErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return fnErr
+}
@@ -0,0 +1,99 @@
+package task
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/leg100/pug/internal"
+)
+
+// DefaultRetryPatterns match the output of tasks that have failed due to a
+// transient error, such as failing to acquire a state lock, being throttled
+// by a provider API, or timing out connecting to a registry.
+var DefaultRetryPatterns = []string{
+	`Error acquiring the state lock`,
+	`(?i)429 Too Many Requests`,
+	`(?i)rate ?limit(ed| exceeded)`,
+	`(?i)throttling`,
+	`(?i)could not connect to registry`,
+	`(?i)failed to (query|retrieve) available provider packages`,
+	`(?i)TLS handshake timeout`,
+	`(?i)i/o timeout`,
+	`(?i)connection reset by peer`,
+}
+
+// maxRetryBackoff is the maximum delay before retrying a task.
+var maxRetryBackoff = 5 * time.Minute
+
+// RetryPolicy determines whether a failed task is automatically retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts at running the task,
+	// including the first attempt. Zero or one means the task is never
+	// retried.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. The delay doubles with each
+	// subsequent retry.
+	Backoff time.Duration
+	// Patterns are matched against the output of a failed task. The task is
+	// only retried if at least one pattern matches.
+	Patterns []*regexp.Regexp
+}
+
+// NewRetryPolicy constructs a retry policy, compiling the given patterns. If no
+// patterns are given then DefaultRetryPatterns are used. A zero policy is
+// returned if retries is zero, i.e. tasks are never retried.
+func NewRetryPolicy(retries int, backoff time.Duration, patterns []string) (RetryPolicy, error) {
+	if retries < 0 {
+		return RetryPolicy{}, fmt.Errorf("invalid number of retries: %d", retries)
+	}
+	if retries == 0 {
+		return RetryPolicy{}, nil
+	}
+	if len(patterns) == 0 {
+		patterns = DefaultRetryPatterns
+	}
+	policy := RetryPolicy{
+		MaxAttempts: retries + 1,
+		Backoff:     backoff,
+		Patterns:    make([]*regexp.Regexp, len(patterns)),
+	}
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return RetryPolicy{}, fmt.Errorf("invalid retry pattern: %w", err)
+		}
+		policy.Patterns[i] = re
+	}
+	return policy, nil
+}
+
+// delay returns the delay before making the given attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 2; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryBackoff)
+}
+
+// retryable determines whether the failed task is to be retried: the task
+// must not have exhausted its attempts and its output must match one of the
+// policy's patterns. A task that timed out is never retried.
+func (t *Task) retryable() bool {
+	if t.Attempt >= t.Retry.MaxAttempts || t.timedOut {
+		return false
+	}
+	out, err := io.ReadAll(t.NewReader(true))
+	if err != nil {
+		return false
+	}
+	stripped := internal.StripAnsi(string(out))
+	for _, re := range t.Retry.Patterns {
+		if re.MatchString(stripped) {
+			return true
+		}
+	}
+	return false
+}
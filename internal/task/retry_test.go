@@ -0,0 +1,100 @@
+package task
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy, err := NewRetryPolicy(0, time.Second, []string{"foo"})
+	require.NoError(t, err)
+	assert.Equal(t, RetryPolicy{}, policy)
+
+	policy, err = NewRetryPolicy(2, time.Second, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, policy.MaxAttempts)
+	assert.Len(t, policy.Patterns, len(DefaultRetryPatterns))
+
+	_, err = NewRetryPolicy(2, time.Second, []string{"("})
+	assert.Error(t, err)
+
+	_, err = NewRetryPolicy(-1, time.Second, nil)
+	assert.Error(t, err)
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	policy := RetryPolicy{Backoff: time.Minute}
+
+	assert.Equal(t, time.Minute, policy.delay(2))
+	assert.Equal(t, 2*time.Minute, policy.delay(3))
+	assert.Equal(t, 4*time.Minute, policy.delay(4))
+	assert.Equal(t, maxRetryBackoff, policy.delay(5))
+	assert.Equal(t, maxRetryBackoff, policy.delay(100))
+}
+
+func TestService_Retry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     10 * time.Millisecond,
+		Patterns:    []*regexp.Regexp{regexp.MustCompile(`Error acquiring the state lock`)},
+	}
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		wantErr  bool
+		wantLen  int
+		wantLast Status
+	}{
+		{"retried", policy, false, 2, Exited},
+		{"no retry policy", RetryPolicy{MaxAttempts: 1}, true, 1, Errored},
+		{
+			"no matching pattern",
+			RetryPolicy{MaxAttempts: 3, Patterns: []*regexp.Regexp{regexp.MustCompile(`429`)}},
+			true,
+			1,
+			Errored,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(ServiceOptions{Logger: logging.Discard})
+			StartEnqueuer(svc)
+			StartRunner(context.Background(), logging.Discard, svc, RunnerOptions{MaxTasks: 1})
+
+			task, err := svc.Create(Spec{
+				Execution: Execution{
+					Program: "./testdata/flaky",
+					Args:    []string{filepath.Join(t.TempDir(), "lock")},
+				},
+				Retry: tt.policy,
+				Wait:  true,
+			})
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			got := svc.List(ListOptions{Oldest: true})
+			require.Len(t, got, tt.wantLen)
+			assert.Equal(t, task, got[0])
+			assert.Equal(t, 1, got[0].Attempt)
+			last := got[len(got)-1]
+			assert.Equal(t, tt.wantLast, last.State)
+			if tt.wantLen > 1 {
+				assert.Equal(t, Errored, got[0].State)
+				assert.Equal(t, &last.ID, got[0].RetriedBy)
+				assert.Equal(t, &got[0].ID, last.RetryOf)
+				assert.Equal(t, 2, last.Attempt)
+			}
+		})
+	}
+}
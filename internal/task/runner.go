@@ -3,25 +3,49 @@ package task
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
 )
 
 // Runner is the global task Runner that provides a couple of invariants:
 // (a) no more than MAX tasks run at any given time
 // (b) no more than one 'exclusive' task runs at any given time
+// (c) no more than the limit of tasks sharing a concurrency key run at any
+// given time
+// (d) no tasks are started whilst the queue is paused, other than immediate
+// tasks
 type runner struct {
-	max   int
-	tasks taskLister
+	max     int
+	tasks   taskLister
+	paused  *atomic.Bool
+	limits  ConcurrencyLimits
+	backend func(moduleID resource.ID) string
+}
+
+// RunnerOptions are options for the task runner.
+type RunnerOptions struct {
+	// MaxTasks is the maximum number of running tasks.
+	MaxTasks int
+	// Limits are limits on the number of running tasks sharing a concurrency
+	// key.
+	Limits ConcurrencyLimits
+	// Backend retrieves the backend type of a module, for the purposes of
+	// deriving concurrency keys.
+	Backend func(moduleID resource.ID) string
 }
 
 // StartRunner starts the task runner and returns a function that waits for
 // running tasks to finish.
-func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service, maxTasks int) func() {
+func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service, opts RunnerOptions) func() {
 	sub := tasks.TaskBroker.Subscribe(context.Background())
 	r := &runner{
-		max:   maxTasks,
-		tasks: tasks,
+		max:     opts.MaxTasks,
+		tasks:   tasks,
+		paused:  &tasks.paused,
+		limits:  opts.Limits,
+		backend: opts.Backend,
 	}
 	g := sync.WaitGroup{}
 
@@ -38,6 +62,14 @@ func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service,
 					g.Add(1)
 					go func() {
 						waitfn()
+						if len(task.postHooks) > 0 {
+							out, err := task.runPostHooks(ctx)
+							if err != nil {
+								logger.Error("running post-hooks", "error", err, "output", string(out), "task", task)
+							} else {
+								logger.Info("ran post-hooks", "output", string(out), "task", task)
+							}
+						}
 						g.Done()
 					}()
 				}
@@ -50,6 +82,7 @@ func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service,
 
 // runnable retrieves a list of tasks to be run
 func (r *runner) runnable() []*Task {
+	paused := r.paused.Load()
 	// exclusive is true if the one and only exclusive slot is occupied
 	var exclusive bool
 
@@ -58,19 +91,46 @@ func (r *runner) runnable() []*Task {
 	})
 	avail := r.max - len(running)
 
-	// Process queue, starting with oldest task
+	// Tally the number of running tasks sharing each concurrency key.
+	tally := make(map[string]int)
+	for _, rt := range running {
+		for key := range r.limits.keys(rt, r.backend) {
+			tally[key]++
+		}
+	}
+
+	// Process queue, starting with the highest priority task, and then the
+	// oldest task.
 	queued := r.tasks.List(ListOptions{
 		Status: []Status{Queued},
 		Oldest: true,
 	})
+	sortByPriority(queued)
 	var i int
 	for _, qt := range queued {
+		if paused && !qt.Immediate {
+			// Hold queued task until the queue is resumed. Immediate tasks,
+			// such as selecting a workspace, are quick and may be awaited by
+			// the user, so they are exempt.
+			continue
+		}
 		if avail <= 0 && !qt.Immediate {
 			// No more available slots. Note: immediate tasks are immediately runnable, so they
 			// are exempt from the max. For this reason the number of slots may
 			// go into negative territory.
 			continue
 		}
+		if qt.group != nil && qt.group.halted.Load() {
+			// Task's group has halted following the failure of another
+			// task; the task is to be canceled.
+			continue
+		}
+		// Immediate tasks are exempt from concurrency limits too.
+		keys := r.limits.keys(qt, r.backend)
+		if !qt.Immediate && r.limited(keys, tally) {
+			// Limit reached for one of the task's concurrency keys.
+			continue
+		}
 		if qt.exclusive {
 			if exclusive {
 				// Exclusive slot taken
@@ -91,8 +151,21 @@ func (r *runner) runnable() []*Task {
 			exclusive = true
 		}
 		avail--
+		for key := range keys {
+			tally[key]++
+		}
 		queued[i] = qt
 		i++
 	}
 	return queued[:i]
 }
+
+// limited returns true if any of the concurrency keys have reached their limit.
+func (r *runner) limited(keys map[string]int, tally map[string]int) bool {
+	for key, limit := range keys {
+		if tally[key] >= limit {
+			return true
+		}
+	}
+	return false
+}
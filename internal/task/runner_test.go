@@ -2,8 +2,10 @@ package task
 
 import (
 	"slices"
+	"sync/atomic"
 	"testing"
 
+	"github.com/leg100/pug/internal/resource"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,6 +18,17 @@ func TestRunner_runnable(t *testing.T) {
 	ex1 := &Task{exclusive: true}
 	ex2 := &Task{exclusive: true}
 	immediate := &Task{Immediate: true}
+	high := &Task{Priority: HighPriority}
+	low := &Task{Priority: LowPriority}
+	mod1 := resource.NewID(resource.Module)
+	mod2 := resource.NewID(resource.Module)
+	mod3 := resource.NewID(resource.Module)
+	s3a := &Task{ModuleID: &mod1}
+	s3b := &Task{ModuleID: &mod1}
+	s3c := &Task{ModuleID: &mod2}
+	gcs := &Task{ModuleID: &mod3}
+	backends := map[resource.ID]string{mod1: "s3", mod2: "s3", mod3: "gcs"}
+	s3Limit := ConcurrencyLimits{{Kind: BackendConcurrency, Pattern: "s3", Limit: 2}}
 
 	tests := []struct {
 		name string
@@ -27,6 +40,10 @@ func TestRunner_runnable(t *testing.T) {
 		running []*Task
 		// Running exclusive tasks
 		exclusive []*Task
+		// Queue is paused
+		paused bool
+		// Concurrency limits
+		limits ConcurrencyLimits
 		// Want these runnable tasks
 		want []*Task
 	}{
@@ -93,11 +110,52 @@ func TestRunner_runnable(t *testing.T) {
 			running: []*Task{t1, t2},
 			want:    []*Task{immediate},
 		},
+		{
+			name:   "higher priority task is runnable ahead of older task",
+			max:    1,
+			queued: []*Task{t1, high},
+			want:   []*Task{high},
+		},
+		{
+			name:   "tasks are runnable in order of priority",
+			max:    2,
+			queued: []*Task{low, t1, high},
+			want:   []*Task{high, t1},
+		},
+		{
+			name:   "only immediate tasks are runnable because the queue is paused",
+			max:    3,
+			queued: []*Task{t1, immediate},
+			paused: true,
+			want:   []*Task{immediate},
+		},
+		{
+			name:   "only two tasks sharing a backend are runnable because of concurrency limit",
+			max:    4,
+			queued: []*Task{s3a, s3b, s3c, gcs},
+			limits: s3Limit,
+			want:   []*Task{s3a, s3b, gcs},
+		},
+		{
+			name:    "no tasks sharing a backend are runnable because concurrency limit already reached",
+			max:     4,
+			queued:  []*Task{s3c, gcs},
+			running: []*Task{s3a, s3b},
+			limits:  s3Limit,
+			want:    []*Task{gcs},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var paused atomic.Bool
+			paused.Store(tt.paused)
 			runner := &runner{
-				max: tt.max,
+				max:    tt.max,
+				paused: &paused,
+				limits: tt.limits,
+				backend: func(moduleID resource.ID) string {
+					return backends[moduleID]
+				},
 				tasks: &fakeRunnerLister{
 					queued:    tt.queued,
 					running:   tt.running,
@@ -1,7 +1,12 @@
 package task
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
 	"slices"
+	"sync/atomic"
+	"time"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
@@ -10,10 +15,13 @@ import (
 )
 
 type Service struct {
-	tasks   *resource.Table[*Task]
-	groups  *resource.Table[*Group]
-	counter *int
-	logger  logging.Interface
+	tasks  *resource.Table[*Task]
+	groups *resource.Table[*Group]
+	logger logging.Interface
+	// paused is true if the task queue is paused.
+	paused atomic.Bool
+	// groupPolicy is the default policy for task groups.
+	groupPolicy GroupPolicy
 
 	TaskBroker  *pubsub.Broker[*Task]
 	GroupBroker *pubsub.Broker[*Group]
@@ -27,22 +35,52 @@ type ServiceOptions struct {
 	UserEnvs   []string
 	UserArgs   []string
 	Terragrunt bool
+	Timeouts   Timeouts
+	// Priorities are the default priorities of types of task, keyed by task
+	// identifier.
+	Priorities map[Identifier]Priority
+	Retry      RetryPolicy
+	Hooks      Hooks
+	// PTY are task identifiers and programs to run in a pseudo-terminal.
+	PTY []string
+	// DataDir is the directory in which task output is spilled to disk. If
+	// empty then task output is kept in memory.
+	DataDir string
+	// MirrorOutput mirrors the output of every task to a file beneath
+	// DataDir/tasks.
+	MirrorOutput bool
+	// GroupPolicy is the default policy for task groups.
+	GroupPolicy GroupPolicy
 }
 
 func NewService(opts ServiceOptions) *Service {
-	var counter int
-
 	taskBroker := pubsub.NewBroker[*Task](opts.Logger)
 	groupBroker := pubsub.NewBroker[*Group](opts.Logger)
 
 	factory := &factory{
 		publisher:  taskBroker,
-		counter:    &counter,
 		program:    opts.Program,
 		workdir:    opts.Workdir,
 		userEnvs:   opts.UserEnvs,
 		userArgs:   opts.UserArgs,
 		terragrunt: opts.Terragrunt,
+		timeouts:   opts.Timeouts,
+		priorities: opts.Priorities,
+		retry:      opts.Retry,
+		hooks:      opts.Hooks,
+		pty:        opts.PTY,
+		logger:     opts.Logger,
+	}
+	if opts.DataDir != "" {
+		factory.bufferDir = filepath.Join(opts.DataDir, "buffers")
+		// Remove output spilled by previous instances that failed to clean up
+		// after themselves.
+		if err := sweepSpillFiles(factory.bufferDir); err != nil {
+			opts.Logger.Error("removing stale task output", "error", err)
+		}
+		if opts.MirrorOutput {
+			factory.mirrorDir = filepath.Join(opts.DataDir, "tasks")
+		}
 	}
 
 	return &Service{
@@ -51,14 +89,51 @@ func NewService(opts ServiceOptions) *Service {
 		TaskBroker:  taskBroker,
 		GroupBroker: groupBroker,
 		factory:     factory,
-		counter:     &counter,
 		logger:      opts.Logger,
+		groupPolicy: opts.GroupPolicy,
 	}
 }
 
 // Create a task. The task is placed into a pending state and requires enqueuing
 // before it'll be processed.
 func (s *Service) Create(spec Spec) (*Task, error) {
+	task, err := s.create(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	wait := make(chan error, 1)
+	go func() {
+		// Wait for the task to finish, and should it fail with a retryable
+		// error, wait for each retry in turn to finish too.
+		for t := task; ; {
+			err := t.Wait()
+			if err != nil {
+				s.logger.Error("task failed", "error", err, "task", t)
+			} else {
+				s.logger.Info("completed task", "task", t)
+			}
+			if err == nil || !t.retrying() {
+				if t.status() != Exited && t.TaskGroupID != nil {
+					s.enforceGroupPolicy(*t.TaskGroupID, t)
+				}
+				wait <- err
+				return
+			}
+			if t, err = s.retry(t); err != nil {
+				s.logger.Error("retrying task", "error", err, "task", t)
+				wait <- err
+				return
+			}
+		}
+	}()
+	if spec.Wait {
+		return task, <-wait
+	}
+	return task, nil
+}
+
+func (s *Service) create(spec Spec) (*Task, error) {
 	task, err := s.newTask(spec)
 	if err != nil {
 		return nil, err
@@ -69,42 +144,213 @@ func (s *Service) Create(spec Spec) (*Task, error) {
 	// Add to db
 	s.tasks.Add(task.ID, task)
 	// Increment counter of number of live tasks
-	*s.counter++
+	s.counter.Add(1)
 
 	if spec.AfterCreate != nil {
 		spec.AfterCreate(task)
 	}
+	return task, nil
+}
 
-	wait := make(chan error, 1)
-	go func() {
-		err := task.Wait()
-		wait <- err
+// retry creates a task to retry a failed task. The new task is linked to the
+// failed task, and added to the failed task's group if it belongs to one. It is
+// only enqueued once its backoff delay has elapsed.
+func (s *Service) retry(failed *Task) (*Task, error) {
+	spec := failed.Spec
+	spec.attempt = failed.Attempt + 1
+	spec.retryOf = &failed.ID
+	spec.TaskGroupID = failed.TaskGroupID
+	spec.group = failed.group
+
+	retry, err := s.create(spec)
+	if err != nil {
+		// Tasks depending on the failed task await its retry, so mark the task
+		// as no longer retrying in order for them to be canceled.
+		s.tasks.Update(failed.ID, func(existing *Task) error {
+			existing.statusMu.Lock()
+			existing.Retrying = false
+			existing.statusMu.Unlock()
+			return nil
+		})
+		return failed, err
+	}
+	s.tasks.Update(failed.ID, func(existing *Task) error {
+		existing.statusMu.Lock()
+		existing.RetriedBy = &retry.ID
+		existing.statusMu.Unlock()
+		return nil
+	})
+	if retry.TaskGroupID != nil {
+		_, err := s.groups.Update(*retry.TaskGroupID, func(existing *Group) error {
+			existing.add(retry)
+			return nil
+		})
 		if err != nil {
-			s.logger.Error("task failed", "error", err, "task", task)
-			return
+			s.logger.Error("adding retry to task group", "error", err, "task", retry)
 		}
-		s.logger.Info("completed task", "task", task)
-	}()
-	if spec.Wait {
-		return task, <-wait
 	}
-	return task, nil
+	// The enqueuer is triggered by task events, so trigger an event once the
+	// delay has elapsed.
+	time.AfterFunc(time.Until(retry.retryAt), func() {
+		s.tasks.Update(retry.ID, func(*Task) error { return nil })
+	})
+	s.logger.Info("retrying task", "task", retry, "attempt", retry.Attempt, "delay", time.Until(retry.retryAt).Round(time.Second))
+	return retry, nil
 }
 
-// Create a task group from one or more task specs. An error is returned if zero
-// specs are provided, or if it fails to create at least one task.
+// Create a task group from one or more task specs, using the default group
+// policy. An error is returned if zero specs are provided, or if it fails to
+// create at least one task.
 func (s *Service) CreateGroup(specs ...Spec) (*Group, error) {
-	g, err := newGroup(s, specs...)
+	return s.CreateGroupWithPolicy(s.groupPolicy, specs...)
+}
+
+// CreateGroupWithPolicy creates a task group with the given policy.
+func (s *Service) CreateGroupWithPolicy(policy GroupPolicy, specs ...Spec) (*Group, error) {
+	g, err := newGroup(s, policy, specs...)
 	if err != nil {
 		return nil, err
 	}
+	s.addCreatedGroup(g)
+	return g, nil
+}
+
+// CreateWorkflowGroup creates a task group from a workflow of steps, each step
+// comprising one or more task specs, using the default group policy. Tasks
+// created in a step depend on the tasks created in the previous step for the
+// same module, and only run once those tasks have succeeded. The group is
+// named after the workflow.
+func (s *Service) CreateWorkflowGroup(name string, steps ...[]Spec) (*Group, error) {
+	g, err := newWorkflowGroup(s, s.groupPolicy, name, steps...)
+	if err != nil {
+		return nil, err
+	}
+	s.addCreatedGroup(g)
+	return g, nil
+}
 
+func (s *Service) addCreatedGroup(g *Group) {
 	s.logger.Debug("created task group", "group", g)
 
 	// Add to db
 	s.AddGroup(g)
 
-	return g, nil
+	// Tasks may have failed before the group was added, in which case their
+	// failure went unenforced, so enforce the group's policy now.
+	if failed := g.Failed(); len(failed) > 0 && g.halted.Load() {
+		s.enforceGroupPolicy(g.ID, failed[0])
+	}
+}
+
+// CancelGroup cancels the remaining tasks in a task group.
+func (s *Service) CancelGroup(groupID resource.ID) ([]*Task, error) {
+	group, err := s.groups.Get(groupID)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		canceled []*Task
+		errs     []error
+	)
+	for _, t := range group.Remaining() {
+		if _, err := s.Cancel(t.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		canceled = append(canceled, t)
+	}
+	return canceled, errors.Join(errs...)
+}
+
+// ReleaseGroup releases the held tasks in a task group, permitting them to be
+// enqueued.
+func (s *Service) ReleaseGroup(groupID resource.ID) ([]*Task, error) {
+	group, err := s.groups.Get(groupID)
+	if err != nil {
+		return nil, err
+	}
+	held := group.Held()
+	if len(held) == 0 {
+		return nil, errors.New("task group has no held tasks")
+	}
+	var (
+		released []*Task
+		errs     []error
+	)
+	for _, t := range held {
+		t, err := s.tasks.Update(t.ID, func(existing *Task) error {
+			existing.statusMu.Lock()
+			existing.Held = false
+			existing.statusMu.Unlock()
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		released = append(released, t)
+	}
+	s.logger.Info("released task group", "group", group, "tasks", len(released))
+	return released, errors.Join(errs...)
+}
+
+// RetryGroup creates a new task group retrying the tasks in a task group that
+// have errored or been canceled. The new group shares the same policy.
+func (s *Service) RetryGroup(groupID resource.ID) (*Group, error) {
+	group, err := s.groups.Get(groupID)
+	if err != nil {
+		return nil, err
+	}
+	failed := group.Failed()
+	if len(failed) == 0 {
+		return nil, errors.New("task group has no failed or canceled tasks")
+	}
+	specs := make([]Spec, len(failed))
+	for i, t := range failed {
+		specs[i] = t.Spec
+	}
+	return s.CreateGroupWithPolicy(group.Policy, specs...)
+}
+
+// enforceGroupPolicy enforces a task group's policy following the failure of
+// one of its tasks.
+func (s *Service) enforceGroupPolicy(groupID resource.ID, failed *Task) {
+	group, err := s.groups.Get(groupID)
+	if err != nil {
+		// The group is yet to be added, in which case the policy is enforced
+		// once it has been added.
+		return
+	}
+	if !group.halted.Load() {
+		// Policy permits remaining tasks to continue.
+		return
+	}
+	reason := fmt.Sprintf("canceled by %s task group policy: task %s failed", group.Policy, failed.ID)
+	for _, t := range group.Remaining() {
+		if t.status() == Running {
+			if group.Policy == StopGroupPolicy {
+				// Leave running tasks to finish.
+				continue
+			}
+		} else {
+			t.output.writer(stdoutStream).Write([]byte(reason))
+		}
+		// Ignore errors: the task may have only just finished, or another
+		// failure may have already enforced the policy.
+		if err := t.cancel(); err == nil {
+			s.logger.Info("canceled task", "task", t, "policy", group.Policy)
+		}
+	}
+}
+
+// RemoveOutput removes the output of all tasks that has been spilled to
+// disk.
+func (s *Service) RemoveOutput() {
+	for _, t := range s.tasks.List() {
+		if err := t.output.remove(); err != nil {
+			s.logger.Error("removing task output", "error", err, "task", t)
+		}
+	}
 }
 
 // AddGroup adds a task group to the DB.
@@ -156,7 +402,7 @@ func (s *Service) List(opts ListOptions) []*Task {
 			continue
 		}
 		if opts.Status != nil {
-			if !slices.Contains(opts.Status, t.State) {
+			if !slices.Contains(opts.Status, t.status()) {
 				continue
 			}
 		}
@@ -177,7 +423,7 @@ func (s *Service) List(opts ListOptions) []*Task {
 
 	// Sort list according to options
 	slices.SortFunc(tasks, func(a, b *Task) int {
-		cmp := a.Updated.Compare(b.Updated)
+		cmp := a.updated().Compare(b.updated())
 		if opts.Oldest {
 			return cmp
 		}
@@ -219,10 +465,15 @@ func (s *Service) Cancel(taskID resource.ID) (*Task, error) {
 func (s *Service) Delete(taskID resource.ID) error {
 	// TODO: only allow deleting task if in finished state (error message should
 	// instruct user to cancel task first).
+	if t, err := s.tasks.Get(taskID); err == nil {
+		if err := t.output.remove(); err != nil {
+			s.logger.Error("removing task output", "error", err, "task", t)
+		}
+	}
 	s.tasks.Delete(taskID)
 	return nil
 }
 
 func (s *Service) Counter() int {
-	return *s.counter
+	return int(s.counter.Load())
 }
@@ -1,10 +1,14 @@
 package task
 
 import (
+	"context"
 	"testing"
 
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/pubsub"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_List(t *testing.T) {
@@ -75,3 +79,58 @@ func TestService_List(t *testing.T) {
 		})
 	}
 }
+
+func TestService_Reprioritize(t *testing.T) {
+	t.Parallel()
+
+	pending := &Task{ID: resource.NewID(resource.Task), State: Pending}
+	running := &Task{ID: resource.NewID(resource.Task), State: Running}
+
+	svc := &Service{
+		tasks:  resource.NewTable(&fakePublisher[*Task]{}),
+		logger: logging.Discard,
+	}
+	svc.tasks.Add(pending.ID, pending)
+	svc.tasks.Add(running.ID, running)
+
+	got, err := svc.Bump(pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, HighPriority, got.Priority)
+
+	got, err = svc.Demote(pending.ID)
+	require.NoError(t, err)
+	got, err = svc.Demote(pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, LowPriority, got.Priority)
+	// A retry of the task retains its priority.
+	assert.Equal(t, LowPriority, (&factory{}).priority(got.Spec))
+
+	_, err = svc.Bump(running.ID)
+	assert.Error(t, err)
+	assert.Equal(t, NormalPriority, running.Priority)
+}
+
+func TestService_PauseResume(t *testing.T) {
+	t.Parallel()
+
+	queued := &Task{ID: resource.NewID(resource.Task), State: Queued}
+
+	broker := pubsub.NewBroker[*Task](logging.Discard)
+	svc := &Service{
+		tasks:  resource.NewTable(broker),
+		logger: logging.Discard,
+	}
+	svc.tasks.Add(queued.ID, queued)
+	sub := broker.Subscribe(context.Background())
+
+	svc.Pause()
+	assert.True(t, svc.Paused())
+
+	// Resuming the queue should trigger an event for the runner to start the
+	// queued task.
+	svc.Resume()
+	assert.False(t, svc.Paused())
+	event := <-sub
+	assert.Equal(t, resource.UpdatedEvent, event.Type)
+	assert.Equal(t, queued, event.Payload)
+}
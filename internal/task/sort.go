@@ -1,17 +1,23 @@
 package task
 
+import "cmp"
+
 // ByState sorts tasks according to the following order:
 //
 // 1. running (ordered by last updated desc)
-// 2. queued (ordered by last updated asc)
-// 3. pending (ordered by last updated asc)
+// 2. queued (ordered by priority desc, then by last updated asc)
+// 3. pending (ordered by priority desc, then by last updated asc)
 // 4. finished (ordered by last updated desc)
 func ByState(i, j *Task) int {
 	switch i.State {
 	case Pending:
 		switch j.State {
 		case Pending:
-			// pending==pending, ordered by last updated desc
+			// pending==pending, ordered by priority, and then by last updated
+			// desc
+			if i.Priority != j.Priority {
+				return cmp.Compare(j.Priority, i.Priority)
+			}
 			if i.Updated.Before(j.Updated) {
 				return 1
 			}
@@ -26,7 +32,11 @@ func ByState(i, j *Task) int {
 	case Queued:
 		switch j.State {
 		case Queued:
-			// queued=queued (ordered by last updated desc)
+			// queued=queued (ordered by priority, and then by last updated
+			// desc)
+			if i.Priority != j.Priority {
+				return cmp.Compare(j.Priority, i.Priority)
+			}
 			if i.Updated.Before(j.Updated) {
 				return 1
 			}
@@ -1,6 +1,11 @@
 package task
 
-import "github.com/leg100/pug/internal/resource"
+import (
+	"strings"
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+)
 
 // Spec is a specification for creating a task.
 type Spec struct {
@@ -16,7 +21,9 @@ type Spec struct {
 	// Execution specifies the execution of a program.
 	Execution Execution
 	// AdditionalExecution specifies the execution of another program. The
-	// program is only executed if the first program exits successfully.
+	// program is only executed if the first program exits successfully. As
+	// with Execution, the program defaults to the `program` pug config
+	// option, in which case the user-supplied CLI args are not added.
 	AdditionalExecution *Execution
 	// Identifier uniquely identifies the type of task.
 	Identifier Identifier
@@ -33,6 +40,20 @@ type Spec struct {
 	JSON bool
 	// Skip queue and immediately start task
 	Immediate bool
+	// Held holds the task back from being enqueued until it is released,
+	// permitting the task to be reviewed before it is run, i.e. a dry-run.
+	Held bool
+	// PTY runs the task's program in a pseudo-terminal, permitting the user to
+	// interact with the program.
+	PTY bool
+	// Diagnostics if true parses terraform diagnostics from the task's output
+	// once the task has finished. If JSON is also true then the output is
+	// expected to be that of `terraform validate -json`.
+	Diagnostics bool
+	// SuccessExitCodes are non-zero exit codes with which the task's program
+	// may exit and yet the task still be deemed successful, e.g. `terraform
+	// fmt -check` exits with 3 if files need formatting.
+	SuccessExitCodes []int
 	// Short if true indicates that the task runtime is short and the output is
 	// minimal.
 	Short bool
@@ -41,6 +62,19 @@ type Spec struct {
 	// Description assigns an optional description to the task to display to the
 	// user, overriding the default of displaying the command.
 	Description string
+	// Timeout is the maximum duration for which the task may run before it is
+	// terminated. If zero then the default timeout for the task is used (see
+	// Timeouts), and if there is no default then the task is never timed out.
+	Timeout time.Duration
+	// Priority determines the order in which the task is enqueued and run
+	// relative to other tasks. If NormalPriority then the default priority for
+	// the task's identifier is used (see ServiceOptions.Priorities), and if
+	// there is no default then the task has normal priority.
+	Priority Priority
+	// Retry specifies a policy for automatically retrying the task should it
+	// fail with a transient error. If MaxAttempts is zero then the default
+	// policy is used.
+	Retry RetryPolicy
 	// Call this function before the task has successfully finished. The
 	// returned string sets the task summary, and the error, if non-nil, deems
 	// the task to have failed and places the task into an errored state.
@@ -68,6 +102,27 @@ type Spec struct {
 	// task can be enqueued. If any of the other tasks are canceled or error
 	// then the task will be canceled.
 	dependsOn []resource.ID
+	// attempt is the number of the attempt at running the task, where zero
+	// or one is the first attempt.
+	attempt int
+	// retryOf is the ID of the failed task that this task retries.
+	retryOf *resource.ID
+	// reprioritized is the priority to which the user bumped or demoted a
+	// task created from the spec, so that retrying the task retains it. It
+	// overrides both Priority and the default priority.
+	reprioritized *Priority
+	// group is the task group this task is to belong to.
+	group *Group
+}
+
+// identifier returns the identifier of the type of task created from the
+// spec. A spec without an identifier is instead identified by its terraform
+// command, e.g. "validate" or "state pull".
+func (s Spec) identifier() Identifier {
+	if s.Identifier == "" && s.Execution.Program == "" {
+		return Identifier(strings.Join(s.Execution.TerraformCommand, " "))
+	}
+	return s.Identifier
 }
 
 // SpecFunc is a function that creates a spec.
@@ -9,18 +9,35 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 
 	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/logging"
 	"github.com/leg100/pug/internal/resource"
 )
 
 // Identifier uniquely identifies the type of task.
 type Identifier string
 
+// validateIdentifier returns an error if the identifier is not one of the
+// known identifiers. It is used to validate identifiers supplied by the user,
+// which would otherwise silently never match a task.
+func validateIdentifier(id Identifier, known []Identifier) error {
+	if slices.Contains(known, id) {
+		return nil
+	}
+	valid := make([]string, len(known))
+	for i, id := range known {
+		valid[i] = string(id)
+	}
+	return fmt.Errorf("unknown task type: %q (valid: %s)", id, strings.Join(valid, ", "))
+}
+
 // Task is an execution of a CLI program.
 type Task struct {
 	resource.ID
@@ -38,18 +55,73 @@ type Task struct {
 	JSON                bool
 	Immediate           bool
 	Short               bool
-	AdditionalEnv       []string
-	DependsOn           []resource.ID
+	// Held is true if the task is held back from being enqueued until it is
+	// released.
+	Held bool
+	// PTY is true if the task's program is run in a pseudo-terminal.
+	PTY           bool
+	AdditionalEnv []string
+	DependsOn     []resource.ID
 	// Summary summarises the outcome of a task to the end-user.
-	Summary     Summary
+	Summary Summary
+	// Diagnostics are the errors and warnings reported by terraform. Only
+	// parsed if the task's spec specifies Diagnostics.
+	Diagnostics []Diagnostic
 	Description string
+	// Timeout is the maximum duration the task may run for. Zero means no
+	// timeout.
+	Timeout time.Duration
+
+	// Priority determines the order in which the task is enqueued and run
+	// relative to other tasks.
+	Priority Priority
+
+	// Retry is the policy for automatically retrying the task.
+	Retry RetryPolicy
+	// Attempt is the number of the attempt at running the task, starting at
+	// 1. It is greater than 1 if the task automatically retries a failed task.
+	Attempt int
+	// RetryOf is the ID of the failed task this task retries. Nil if the task
+	// is the first attempt.
+	RetryOf *resource.ID
+	// RetriedBy is the ID of the task retrying this task. Nil if the task has
+	// not been retried.
+	RetriedBy *resource.ID
+	// Retrying is true if the task failed and is to be automatically retried.
+	Retrying bool
+
+	// timedOut is true if the task exceeded its timeout.
+	timedOut bool
+	// retryAt is the time before which the task is not enqueued, giving a
+	// transient error time to clear before the task is retried.
+	retryAt time.Time
+
+	// group is the task group the task belongs to. Nil if the task does not
+	// belong to a group.
+	group *Group
+
+	// preHooks are run before the task's program, and postHooks are run after
+	// the task finishes.
+	preHooks  []Execution
+	postHooks []Execution
+	// PostHookOutput is the combined output of the post-hooks, and PostHookErr
+	// is non-nil if a post-hook failed. Both are only set once the post-hooks
+	// have run.
+	PostHookOutput string
+	PostHookErr    error
 
 	exclusive bool
+	// mirrorDir is the directory to which the task's output is mirrored. Empty
+	// if the output is not mirrored.
+	mirrorDir string
 	// terragrunt is true if terragrunt is in use.
 	terragrunt bool
 
 	// Nil until task has started
 	proc *os.Process
+	// pty is the pseudo-terminal in which the task's program is running. Nil
+	// if the task is not running in a pseudo-terminal.
+	pty *pty
 
 	Created time.Time
 	Updated time.Time
@@ -57,13 +129,16 @@ type Task struct {
 	// Nil until task finishes with an error
 	Err error
 
-	// stdout contains only the stdout stream
-	stdout *buffer
-	// combined contains both the stderr and stdout streams
-	combined *buffer
+	// output contains both the stdout and stderr streams
+	output *buffer
 
 	// lock to ensure task state is switched atomically.
 	mu sync.Mutex
+	// statusMu guards the fields that change as the task progresses and that
+	// are read by the enqueuer and runner on other goroutines: State, Updated,
+	// Retrying, Held and RetriedBy. Unlike mu it is never held while callbacks
+	// are invoked.
+	statusMu sync.RWMutex
 
 	// this channel is closed once the task is finished
 	finished chan struct{}
@@ -89,7 +164,9 @@ type Task struct {
 }
 
 type factory struct {
-	counter   *int
+	// counter is the number of live tasks. It is updated from the goroutines
+	// of tasks as they finish, so it is atomic.
+	counter   atomic.Int64
 	program   string
 	publisher resource.Publisher[*Task]
 	workdir   internal.Workdir
@@ -99,6 +176,22 @@ type factory struct {
 	userArgs []string
 	// Terragrunt mode
 	terragrunt bool
+	// Default timeouts
+	timeouts Timeouts
+	// Default priorities of types of task
+	priorities map[Identifier]Priority
+	// Default retry policy
+	retry RetryPolicy
+	// Pre- and post-task hooks
+	hooks Hooks
+	// Task identifiers and programs to run in a pseudo-terminal
+	pty []string
+	// Directory in which to spill task output
+	bufferDir string
+	logger    logging.Interface
+	// Directory to which to mirror task output. Empty if output is not
+	// mirrored.
+	mirrorDir string
 }
 
 // Summary summarises the outcome of a task.
@@ -115,6 +208,9 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 	if spec.Blocking && spec.Immediate {
 		return nil, errors.New("a task cannot both be blocking and immediately")
 	}
+	if spec.Held && spec.Wait {
+		return nil, errors.New("a held task cannot be waited upon")
+	}
 	task := &Task{
 		ID:                  resource.NewID(resource.Task),
 		ModuleID:            spec.ModuleID,
@@ -125,9 +221,9 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 		Created:             time.Now(),
 		Updated:             time.Now(),
 		finished:            make(chan struct{}),
-		stdout:              newBuffer(),
-		combined:            newBuffer(),
+		output:              newBuffer(f.bufferDir, f.logger),
 		terragrunt:          f.terragrunt,
+		mirrorDir:           f.mirrorDir,
 		Path:                filepath.Join(f.workdir.String(), spec.Path),
 		AdditionalExecution: spec.AdditionalExecution,
 		AdditionalEnv:       append(f.userEnvs, spec.Env...),
@@ -136,8 +232,15 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 		DependsOn:           spec.dependsOn,
 		Immediate:           spec.Immediate,
 		Short:               spec.Short,
+		Held:                spec.Held,
 		exclusive:           spec.Exclusive,
 		Description:         spec.Description,
+		Timeout:             f.timeouts.timeout(spec),
+		Priority:            f.priority(spec),
+		Retry:               spec.Retry,
+		Attempt:             max(spec.attempt, 1),
+		RetryOf:             spec.retryOf,
+		group:               spec.group,
 		Spec:                spec,
 		AfterCreate:         spec.AfterCreate,
 		AfterRunning:        spec.AfterRunning,
@@ -157,7 +260,7 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 		},
 		// Decrement live task counter whenever task terminates
 		afterFinish: func(t *Task) {
-			*f.counter--
+			f.counter.Add(-1)
 		},
 		timestamps: map[Status]statusTimestamps{
 			Pending: {
@@ -165,6 +268,23 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 			},
 		},
 	}
+	task.PTY = spec.PTY || f.usePTY(spec)
+	task.preHooks = f.hooks.Pre[spec.identifier()]
+	task.postHooks = f.hooks.Post[spec.identifier()]
+	if task.Retry.MaxAttempts == 0 {
+		task.Retry = f.retry
+	}
+	if task.Attempt > 1 {
+		task.retryAt = task.Created.Add(task.Retry.delay(task.Attempt))
+	}
+	// A retained spec that is retried manually starts afresh with a first
+	// attempt, outside of the task group, and is not held.
+	task.Spec.attempt = 0
+	task.Spec.retryOf = nil
+	task.Spec.TaskGroupID = nil
+	task.Spec.group = nil
+	task.Spec.Held = false
+
 	// Determine the program and the args to pass to program.
 	if spec.Execution.Program == "" {
 		// Is terraform task
@@ -176,6 +296,13 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 	}
 	task.Args = append(task.Args, f.userArgs...)
 	task.Args = append(task.Args, spec.Execution.Args...)
+	if spec.AdditionalExecution != nil && spec.AdditionalExecution.Program == "" {
+		// Additional execution is a terraform execution
+		task.AdditionalExecution = &Execution{
+			Program: f.program,
+			Args:    append(slices.Clone(spec.AdditionalExecution.TerraformCommand), spec.AdditionalExecution.Args...),
+		}
+	}
 
 	// If description is not explicitly set then set it using provided terraform
 	// commands or - if this is not a terraform execution - then using the
@@ -199,6 +326,16 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 	return task, nil
 }
 
+// usePTY determines whether a task created from the spec is run in a
+// pseudo-terminal, either because its identifier or its program is
+// configured to be run in one.
+func (f *factory) usePTY(spec Spec) bool {
+	if slices.Contains(f.pty, string(spec.identifier())) {
+		return true
+	}
+	return spec.Execution.Program != "" && slices.Contains(f.pty, spec.Execution.Program)
+}
+
 func (t *Task) String() string {
 	return t.Description
 }
@@ -207,20 +344,17 @@ func (t *Task) String() string {
 // the task buffer.
 // Set combined to true to receieve stderr as well as stdout.
 func (t *Task) NewReader(combined bool) io.Reader {
-	if combined {
-		return t.combined.NewReader()
-	}
-	return t.stdout.NewReader()
+	return t.output.NewReader(combined)
 }
 
 // NewStreamer returns a stream of output from the task; the channel is closed
 // when the task has finished.
 func (t *Task) NewStreamer() <-chan []byte {
-	return t.combined.Stream()
+	return t.output.Stream()
 }
 
 func (t *Task) IsActive() bool {
-	switch t.State {
+	switch t.status() {
 	case Queued, Running:
 		return true
 	default:
@@ -228,6 +362,44 @@ func (t *Task) IsActive() bool {
 	}
 }
 
+// status returns the task's current status. It is safe to call while the task
+// is being updated on another goroutine.
+func (t *Task) status() Status {
+	t.statusMu.RLock()
+	defer t.statusMu.RUnlock()
+	return t.State
+}
+
+// updated returns the time at which the task's status last changed.
+func (t *Task) updated() time.Time {
+	t.statusMu.RLock()
+	defer t.statusMu.RUnlock()
+	return t.Updated
+}
+
+// retrying returns true if the task failed and is to be automatically
+// retried.
+func (t *Task) retrying() bool {
+	t.statusMu.RLock()
+	defer t.statusMu.RUnlock()
+	return t.Retrying
+}
+
+// held returns true if the task is held back from being enqueued.
+func (t *Task) held() bool {
+	t.statusMu.RLock()
+	defer t.statusMu.RUnlock()
+	return t.Held
+}
+
+// retriedBy returns the ID of the task retrying this task, or nil if the task
+// has not been retried.
+func (t *Task) retriedBy() *resource.ID {
+	t.statusMu.RLock()
+	defer t.statusMu.RUnlock()
+	return t.RetriedBy
+}
+
 // Elapsed returns the length of time the task has been in the given status.
 func (t *Task) Elapsed(s Status) time.Duration {
 	st, ok := t.timestamps[s]
@@ -241,7 +413,7 @@ func (t *Task) Elapsed(s Status) time.Duration {
 // then the returned error is non-nil.
 func (t *Task) Wait() error {
 	<-t.finished
-	if t.State != Exited {
+	if t.status() != Exited {
 		return t.Err
 	}
 	return nil
@@ -294,7 +466,16 @@ func (t *Task) cancel() error {
 }
 
 func (t *Task) start(ctx context.Context) (func(), error) {
-	cmd := t.execute(ctx, t.Program, t.Args)
+	// Run any pre-hooks first, then the program, and then any additional
+	// program, each only once the previous one has exited successfully.
+	cmds := make([]*exec.Cmd, 0, len(t.preHooks)+2)
+	for _, hook := range t.preHooks {
+		cmds = append(cmds, t.executeHook(ctx, hook, Running))
+	}
+	cmds = append(cmds, t.execute(ctx, t.Program, t.Args))
+	if t.AdditionalExecution != nil {
+		cmds = append(cmds, t.execute(ctx, t.AdditionalExecution.Program, t.AdditionalExecution.Args))
+	}
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -303,30 +484,92 @@ func (t *Task) start(ctx context.Context) (func(), error) {
 		return nil, errors.New("invalid state transition")
 	}
 
-	if err := cmd.Start(); err != nil {
+	if t.mirrorDir != "" {
+		if err := t.mirrorOutput(t.mirrorDir); err != nil {
+			// Not fatal; let the user know the output is not being mirrored.
+			fmt.Fprintf(t.output.writer(stderrStream), "pug: mirroring output: %s\n", err)
+		}
+	}
+
+	// The pre-hooks are not run in a pseudo-terminal.
+	usePTY := func(i int) bool {
+		return t.PTY && i >= len(t.preHooks)
+	}
+
+	if err := t.startCmd(cmds[0], usePTY(0)); err != nil {
 		t.updateState(Errored)
-		t.Err = fmt.Errorf("starting task: %w", err)
+		if len(t.preHooks) > 0 {
+			t.Err = fmt.Errorf("starting pre-hook: %w", err)
+		} else {
+			t.Err = fmt.Errorf("starting task: %w", err)
+		}
 		return nil, err
 	}
 	t.updateState(Running)
 	// save reference to process so that it can be cancelled via cancel()
-	t.proc = cmd.Process
+	t.proc = cmds[0].Process
+
+	// If the task has a timeout then watch each process it starts, terminating
+	// the process if the deadline is exceeded. The returned function stops
+	// watching the process.
+	deadline := time.Now().Add(t.Timeout)
+	watch := func(proc *os.Process) func() {
+		if t.Timeout == 0 {
+			return func() {}
+		}
+		done := make(chan struct{})
+		go t.watchTimeout(proc, deadline, done)
+		return func() { close(done) }
+	}
+	stop := watch(cmds[0].Process)
+
+	// waitCmd waits for the i-th command to exit, deeming any of the task's
+	// success exit codes to be successful, unless the command is a pre-hook.
+	waitCmd := func(i int) error {
+		err := t.waitCmd(cmds[i])
+		var exitErr *exec.ExitError
+		if i >= len(t.preHooks) && errors.As(err, &exitErr) && slices.Contains(t.Spec.SuccessExitCodes, exitErr.ExitCode()) {
+			return nil
+		}
+		return err
+	}
 
 	wait := func() {
+		err := waitCmd(0)
+		stop()
+		// last is the index of the last command to be run.
+		last := 0
+		for i := 1; err == nil && i < len(cmds); i++ {
+			last = i
+			t.mu.Lock()
+			if err = t.startCmd(cmds[i], usePTY(i)); err == nil {
+				t.proc = cmds[i].Process
+			}
+			t.mu.Unlock()
+			if err == nil {
+				stop = watch(cmds[i].Process)
+				err = waitCmd(i)
+				stop()
+			}
+		}
+
+		t.mu.Lock()
 		state := Exited
-		if err := cmd.Wait(); err != nil {
+		if t.timedOut {
+			state = Errored
+			t.Err = fmt.Errorf("%w after %s", ErrTimedOut, t.Timeout)
+		} else if err != nil && last < len(t.preHooks) {
+			// A pre-hook failed, preventing the program from running.
+			state = Errored
+			t.Err = fmt.Errorf("pre-hook failed: %s: %w", t.preHooks[last], err)
+		} else if err != nil {
 			state = Errored
 			t.Err = fmt.Errorf("task failed: %w", err)
-		} else if t.AdditionalExecution != nil {
-			// Execute additional program.
-			cmd = t.execute(ctx, t.AdditionalExecution.Program, t.AdditionalExecution.Args)
-			if err := cmd.Run(); err != nil {
-				state = Errored
-				t.Err = fmt.Errorf("task failed: %w", err)
+			// Attach lock info if the task failed to acquire the state lock.
+			if info, ok := parseLockInfo(t.output.NewReader(true)); ok {
+				t.Err = &LockError{LockInfo: info, err: t.Err}
 			}
 		}
-
-		t.mu.Lock()
 		t.updateState(state)
 		t.mu.Unlock()
 	}
@@ -342,8 +585,8 @@ func (t *Task) execute(ctx context.Context, program string, args []string) *exec
 		return cmd.Process.Signal(os.Interrupt)
 	}
 	cmd.Dir = t.Path
-	cmd.Stdout = io.MultiWriter(t.stdout, t.combined)
-	cmd.Stderr = t.combined
+	cmd.Stdout = t.output.writer(stdoutStream)
+	cmd.Stderr = t.output.writer(stderrStream)
 	cmd.Env = append(t.AdditionalEnv, os.Environ()...)
 	return cmd
 }
@@ -357,7 +600,9 @@ func (t *Task) recordStatusEndTime(now time.Time) {
 
 func (t *Task) updateState(state Status) {
 	now := time.Now()
+	t.statusMu.Lock()
 	t.Updated = now
+	t.statusMu.Unlock()
 
 	// record times at which old status ended, and new status started
 	t.recordStatusEndTime(now)
@@ -368,8 +613,7 @@ func (t *Task) updateState(state Status) {
 	// Close output streams. It's important this is done before BeforeExited is
 	// called because it may want to consume the output streams until EOF.
 	if state.IsFinal() {
-		t.stdout.Close()
-		t.combined.Close()
+		t.output.Close()
 	}
 
 	// Before task exits trigger callback and if it fails set task's status to
@@ -382,8 +626,27 @@ func (t *Task) updateState(state Status) {
 		}
 		t.Summary = summary
 	}
+	if (state == Exited || state == Errored) && t.Spec.Diagnostics {
+		t.setDiagnostics()
+	}
+	// Determine whether a failed task is to be retried before publishing the
+	// failure, so that tasks depending upon this task await the retry rather
+	// than being canceled.
+	if state == Errored {
+		retrying := t.retryable()
+		t.statusMu.Lock()
+		t.Retrying = retrying
+		t.statusMu.Unlock()
+	}
+	// Likewise, halt the task's group before publishing the failure, so that
+	// no further tasks in the group are started.
+	if t.group != nil && ((state == Errored && !t.Retrying) || state == Canceled) {
+		t.group.halt()
+	}
 
+	t.statusMu.Lock()
 	t.State = state
+	t.statusMu.Unlock()
 	if t.afterUpdate != nil {
 		t.afterUpdate(t)
 	}
@@ -7,7 +7,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/leg100/pug/internal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,7 +15,6 @@ func TestTask_NewReader(t *testing.T) {
 	t.Parallel()
 
 	f := factory{
-		counter:   internal.Int(0),
 		program:   "./testdata/task",
 		publisher: &fakePublisher[*Task]{},
 	}
@@ -51,7 +49,6 @@ func TestTask_cancel(t *testing.T) {
 	t.Parallel()
 
 	f := factory{
-		counter:   internal.Int(0),
 		program:   "./testdata/killme",
 		publisher: &fakePublisher[*Task]{},
 	}
@@ -75,6 +72,55 @@ func TestTask_cancel(t *testing.T) {
 	assert.Equal(t, Exited, task.State)
 }
 
+func TestTask_SuccessExitCodes(t *testing.T) {
+	f := factory{publisher: &fakePublisher[*Task]{}}
+
+	for _, tt := range []struct {
+		name  string
+		codes []int
+		want  Status
+	}{
+		{"exit code deemed successful", []int{3}, Exited},
+		{"exit code not deemed successful", []int{2}, Errored},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := f.newTask(Spec{
+				Execution:        Execution{Program: "sh", Args: []string{"-c", "exit 3"}},
+				SuccessExitCodes: tt.codes,
+			})
+			require.NoError(t, err)
+			task.updateState(Queued)
+
+			waitfn, err := task.start(context.Background())
+			require.NoError(t, err)
+			waitfn()
+
+			assert.Equal(t, tt.want, task.State)
+		})
+	}
+}
+
+func TestTask_AdditionalExecution(t *testing.T) {
+	f := factory{publisher: &fakePublisher[*Task]{}, program: "echo", userArgs: []string{"-no-color"}}
+
+	task, err := f.newTask(Spec{
+		Execution:           Execution{TerraformCommand: []string{"plan"}},
+		AdditionalExecution: &Execution{TerraformCommand: []string{"show"}, Args: []string{"-json"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &Execution{Program: "echo", Args: []string{"show", "-json"}}, task.AdditionalExecution)
+
+	task.updateState(Queued)
+	waitfn, err := task.start(context.Background())
+	require.NoError(t, err)
+	waitfn()
+
+	assert.Equal(t, Exited, task.State)
+	got, err := io.ReadAll(task.NewReader(false))
+	require.NoError(t, err)
+	assert.Equal(t, "plan -no-color\nshow -json\n", string(got))
+}
+
 func TestStripError(t *testing.T) {
 	b, err := os.ReadFile("./testdata/validate.out")
 	require.NoError(t, err)
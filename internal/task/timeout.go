@@ -0,0 +1,118 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrTimedOut is the error reported when a task exceeds its timeout.
+var ErrTimedOut = errors.New("task timed out")
+
+var (
+	// interruptGracePeriod is how long a timed out task is given to exit
+	// following an interrupt signal before it is sent a terminate signal.
+	interruptGracePeriod = 30 * time.Second
+	// terminateGracePeriod is how long a timed out task is given to exit
+	// following a terminate signal before it is killed.
+	terminateGracePeriod = 10 * time.Second
+)
+
+// Timeouts specifies default timeouts for tasks.
+type Timeouts struct {
+	// Default is the timeout for tasks without a more specific timeout. Zero
+	// means tasks are never timed out.
+	Default time.Duration
+	// Identifiers maps task identifiers to timeouts. A task without an
+	// identifier is instead matched on its terraform command, e.g. "validate"
+	// or "state pull".
+	Identifiers map[Identifier]time.Duration
+}
+
+// ParseTimeouts parses timeouts, each either a duration, which sets the
+// default timeout, or a task identifier and duration separated by an equals
+// sign, e.g. apply=1h. The identifier must be one of the known identifiers.
+func ParseTimeouts(values []string, known []Identifier) (Timeouts, error) {
+	var timeouts Timeouts
+	for _, v := range values {
+		id, d, found := strings.Cut(v, "=")
+		if !found {
+			d = id
+		}
+		duration, err := time.ParseDuration(d)
+		if err != nil {
+			return Timeouts{}, fmt.Errorf("parsing timeout: %w", err)
+		}
+		if duration < 0 {
+			return Timeouts{}, fmt.Errorf("timeout cannot be negative: %s", v)
+		}
+		if !found {
+			timeouts.Default = duration
+			continue
+		}
+		if err := validateIdentifier(Identifier(id), known); err != nil {
+			return Timeouts{}, fmt.Errorf("parsing timeout: %w", err)
+		}
+		if timeouts.Identifiers == nil {
+			timeouts.Identifiers = make(map[Identifier]time.Duration)
+		}
+		timeouts.Identifiers[Identifier(id)] = duration
+	}
+	return timeouts, nil
+}
+
+// timeout determines the timeout for a task created from the spec.
+func (t Timeouts) timeout(spec Spec) time.Duration {
+	if spec.Timeout > 0 {
+		return spec.Timeout
+	}
+	if d, ok := t.Identifiers[spec.identifier()]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// watchTimeout terminates the process once the deadline is reached, first
+// sending an interrupt signal, then a terminate signal, and finally killing
+// the process, giving it a grace period to exit after each signal. It returns
+// early once the done channel is closed, which should happen when the process
+// exits.
+func (t *Task) watchTimeout(proc *os.Process, deadline time.Time, done <-chan struct{}) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return
+	case <-timer.C:
+	}
+
+	t.mu.Lock()
+	t.timedOut = true
+	t.mu.Unlock()
+
+	steps := []struct {
+		signal func() error
+		grace  time.Duration
+	}{
+		{func() error { return proc.Signal(os.Interrupt) }, interruptGracePeriod},
+		{func() error { return proc.Signal(syscall.SIGTERM) }, terminateGracePeriod},
+		{proc.Kill, 0},
+	}
+	for _, step := range steps {
+		// Ignore errors; the process may have only just exited.
+		_ = step.signal()
+		if step.grace == 0 {
+			return
+		}
+		timer.Reset(step.grace)
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    Timeouts
+		wantErr bool
+	}{
+		{"none", nil, Timeouts{}, false},
+		{"default", []string{"1h"}, Timeouts{Default: time.Hour}, false},
+		{
+			"identifiers",
+			[]string{"30m", "apply=2h", "state pull=1m"},
+			Timeouts{
+				Default: 30 * time.Minute,
+				Identifiers: map[Identifier]time.Duration{
+					"apply":      2 * time.Hour,
+					"state pull": time.Minute,
+				},
+			},
+			false,
+		},
+		{"invalid duration", []string{"apply=forever"}, Timeouts{}, true},
+		{"unknown identifier", []string{"aply=2h"}, Timeouts{}, true},
+		{"negative duration", []string{"-1h"}, Timeouts{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimeouts(tt.values, []Identifier{"apply", "state pull"})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTimeouts_timeout(t *testing.T) {
+	timeouts := Timeouts{
+		Default: time.Hour,
+		Identifiers: map[Identifier]time.Duration{
+			"apply":    2 * time.Hour,
+			"validate": time.Minute,
+		},
+	}
+	tests := []struct {
+		name string
+		spec Spec
+		want time.Duration
+	}{
+		{"spec overrides defaults", Spec{Identifier: "apply", Timeout: time.Second}, time.Second},
+		{"identifier", Spec{Identifier: "apply"}, 2 * time.Hour},
+		{"terraform command", Spec{Execution: Execution{TerraformCommand: []string{"validate"}}}, time.Minute},
+		{"program", Spec{Execution: Execution{Program: "validate"}}, time.Hour},
+		{"default", Spec{Identifier: "plan"}, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, timeouts.timeout(tt.spec))
+		})
+	}
+}
+
+func TestTask_timeout(t *testing.T) {
+	// Shorten grace periods for the duration of the test.
+	origInterrupt, origTerminate := interruptGracePeriod, terminateGracePeriod
+	interruptGracePeriod, terminateGracePeriod = 100*time.Millisecond, 100*time.Millisecond
+	t.Cleanup(func() {
+		interruptGracePeriod, terminateGracePeriod = origInterrupt, origTerminate
+	})
+
+	tests := []struct {
+		name    string
+		program string
+	}{
+		// killme exits upon receiving an interrupt signal.
+		{"interrupted", "./testdata/killme"},
+		// hangme ignores both interrupt and terminate signals, and has to be
+		// killed.
+		{"killed", "./testdata/hangme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := factory{
+				program:   tt.program,
+				publisher: &fakePublisher[*Task]{},
+			}
+			task, err := f.newTask(Spec{Timeout: 100 * time.Millisecond})
+			require.NoError(t, err)
+			task.updateState(Queued)
+
+			waitfn, err := task.start(context.Background())
+			require.NoError(t, err)
+
+			done := make(chan struct{})
+			go func() {
+				waitfn()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for task to be terminated")
+			}
+			assert.Equal(t, Errored, task.State)
+			assert.ErrorIs(t, task.Err, ErrTimedOut)
+		})
+	}
+}
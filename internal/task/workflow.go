@@ -0,0 +1,89 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// newWorkflowGroup constructs a task group from a workflow of steps. The tasks
+// of each step depend on the latest tasks of previous steps that share the same
+// module, and if both belong to a workspace, the same workspace.
+func newWorkflowGroup(service taskCreator, policy GroupPolicy, name string, steps ...[]Spec) (*Group, error) {
+	if len(steps) == 0 {
+		return nil, errors.New("no steps provided")
+	}
+	g, err := newEmptyGroup(policy)
+	if err != nil {
+		return nil, err
+	}
+	g.Command = name
+
+	var (
+		// latest are the most recently created tasks of each module and
+		// workspace. A task depends on those of the latest tasks that precede
+		// it, which need not have been created in the immediately preceding
+		// step.
+		latest []*Task
+		// failed are the modules for which a task failed to be created. No
+		// further tasks are created for the module because the tasks would not
+		// be able to depend on the failed task.
+		failed = make(map[resource.ID]struct{})
+	)
+	for i, step := range steps {
+		var created []*Task
+		for _, spec := range step {
+			if spec.ModuleID == nil {
+				g.CreateErrors = append(g.CreateErrors, fmt.Errorf("step %d: task does not belong to a module", i+1))
+				continue
+			}
+			if _, ok := failed[*spec.ModuleID]; ok {
+				g.CreateErrors = append(g.CreateErrors, fmt.Errorf("step %d: skipped task on module %s: previous step failed", i+1, spec.ModuleID))
+				continue
+			}
+			spec.TaskGroupID = &g.ID
+			spec.group = g
+			spec.Dependencies = nil
+			spec.dependsOn = nil
+			for _, t := range latest {
+				if precedes(t, spec) {
+					spec.dependsOn = append(spec.dependsOn, t.ID)
+				}
+			}
+			task, err := service.Create(spec)
+			if err != nil {
+				g.CreateErrors = append(g.CreateErrors, fmt.Errorf("step %d: %w", i+1, err))
+				failed[*spec.ModuleID] = struct{}{}
+				continue
+			}
+			created = append(created, task)
+			g.Tasks = append(g.Tasks, task)
+		}
+		// Each created task supersedes the tasks it depends upon, because it
+		// cannot finish before they do.
+		for _, t := range created {
+			latest = slices.DeleteFunc(latest, func(prev *Task) bool {
+				return slices.Contains(t.DependsOn, prev.ID)
+			})
+			latest = append(latest, t)
+		}
+	}
+	if len(g.Tasks) == 0 {
+		return g, errors.New("all tasks failed to be created")
+	}
+	return g, nil
+}
+
+// precedes determines whether a task created in a previous step of a workflow
+// precedes a task to be created from the spec.
+func precedes(t *Task, spec Spec) bool {
+	if t.ModuleID == nil || *t.ModuleID != *spec.ModuleID {
+		return false
+	}
+	if t.WorkspaceID != nil && spec.WorkspaceID != nil {
+		return *t.WorkspaceID == *spec.WorkspaceID
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWorkflowGroup(t *testing.T) {
+	mod1 := resource.NewID(resource.Module)
+	mod2 := resource.NewID(resource.Module)
+	ws1 := resource.NewID(resource.Workspace)
+	ws2 := resource.NewID(resource.Workspace)
+	ws3 := resource.NewID(resource.Workspace)
+
+	group, err := newWorkflowGroup(&fakeTaskCreator{}, ContinueGroupPolicy, "check",
+		// init
+		[]Spec{{ModuleID: &mod1}, {ModuleID: &mod2}},
+		// plan
+		[]Spec{
+			{ModuleID: &mod1, WorkspaceID: &ws1},
+			{ModuleID: &mod1, WorkspaceID: &ws2},
+			{ModuleID: &mod2, WorkspaceID: &ws3},
+		},
+		// plan again
+		[]Spec{{ModuleID: &mod1, WorkspaceID: &ws1}},
+		// validate
+		[]Spec{{ModuleID: &mod1}, {ModuleID: &mod2}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "check", group.Command)
+	require.Len(t, group.Tasks, 8)
+
+	init1, init2 := group.Tasks[0], group.Tasks[1]
+	plan1, plan2, plan3 := group.Tasks[2], group.Tasks[3], group.Tasks[4]
+	replan1 := group.Tasks[5]
+	validate1, validate2 := group.Tasks[6], group.Tasks[7]
+
+	assert.Empty(t, init1.DependsOn)
+	assert.Empty(t, init2.DependsOn)
+	assert.Equal(t, []resource.ID{init1.ID}, plan1.DependsOn)
+	assert.Equal(t, []resource.ID{init1.ID}, plan2.DependsOn)
+	assert.Equal(t, []resource.ID{init2.ID}, plan3.DependsOn)
+	assert.Equal(t, []resource.ID{plan1.ID}, replan1.DependsOn)
+	// validate depends on the latest task of each workspace of the module,
+	// including those for which the previous step created no task.
+	assert.Equal(t, []resource.ID{plan2.ID, replan1.ID}, validate1.DependsOn)
+	assert.Equal(t, []resource.ID{plan3.ID}, validate2.DependsOn)
+
+	for _, task := range group.Tasks {
+		assert.Equal(t, group.ID, *task.TaskGroupID)
+	}
+}
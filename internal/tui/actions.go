@@ -2,10 +2,10 @@ package tui
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/plan"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/task"
@@ -16,6 +16,13 @@ import (
 type ActionHandler struct {
 	*Helpers
 	IDRetriever
+
+	// withArgs is true if the next action is to prompt the user to edit the
+	// args of the command before it is run.
+	withArgs bool
+	// dryRun is true if the tasks of the next action are to be held for
+	// review rather than run.
+	dryRun bool
 }
 
 type IDRetriever interface {
@@ -33,6 +40,21 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 	)
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Common.WithArgs):
+			m.withArgs = true
+			return ReportInfo("Press the key of an action to edit its args before running it")
+		case key.Matches(msg, keys.Common.DryRun):
+			m.dryRun = true
+			return ReportInfo("Press the key of an action to dry-run it")
+		}
+		// Only the action immediately following the keys is run with args or
+		// dry-run.
+		defer func() {
+			m.withArgs = false
+			m.dryRun = false
+		}()
+
 		switch {
 		case key.Matches(msg, keys.Common.InitUpgrade):
 			upgrade = true
@@ -45,7 +67,7 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 			fn := func(moduleID resource.ID) (task.Spec, error) {
 				return m.Modules.Init(moduleID, upgrade)
 			}
-			return m.CreateTasks(fn, ids...)
+			return m.createTasks(fn, ids...)
 		case key.Matches(msg, keys.Common.Execute):
 			ids, err := m.GetModuleIDs()
 			if err != nil {
@@ -59,13 +81,19 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 						return nil
 					}
 					// split value into program and any args
-					parts := strings.Split(v, " ")
+					parts, err := internal.SplitWords(v)
+					if err != nil {
+						return ReportError(err)
+					}
+					if len(parts) == 0 {
+						return nil
+					}
 					prog := parts[0]
 					args := parts[1:]
 					fn := func(moduleID resource.ID) (task.Spec, error) {
 						return m.Modules.Execute(moduleID, prog, args...)
 					}
-					return m.CreateTasks(fn, ids...)
+					return m.createTasks(fn, ids...)
 				},
 				Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
 				Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
@@ -75,15 +103,26 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 			if err != nil {
 				return ReportError(err)
 			}
-			cmd := m.CreateTasks(m.Modules.Validate, ids...)
+			cmd := m.createTasks(m.Modules.Validate, ids...)
 			return cmd
 		case key.Matches(msg, keys.Common.Format):
 			ids, err := m.GetModuleIDs()
 			if err != nil {
 				return ReportError(err)
 			}
-			cmd := m.CreateTasks(m.Modules.Format, ids...)
+			cmd := m.createTasks(m.Modules.Format, ids...)
 			return cmd
+		case key.Matches(msg, keys.Common.FormatCheck):
+			ids, err := m.GetModuleIDs()
+			if err != nil {
+				return ReportError(err)
+			}
+			// Send the user to the diffs of the modules that need formatting
+			// rather than to the tasks.
+			return tea.Sequence(
+				m.createTasks(m.Modules.FormatCheck, ids...),
+				NavigateTo(FormatDiffKind),
+			)
 		case key.Matches(msg, keys.Common.PlanDestroy):
 			createPlanOptions.Destroy = true
 			fallthrough
@@ -95,7 +134,7 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 			fn := func(workspaceID resource.ID) (task.Spec, error) {
 				return m.Plans.Plan(workspaceID, createPlanOptions)
 			}
-			return m.CreateTasks(fn, ids...)
+			return m.createTasks(fn, ids...)
 		case key.Matches(msg, keys.Common.Destroy):
 			createPlanOptions.Destroy = true
 			applyPrompt = "Destroy resources of %d workspaces?"
@@ -105,12 +144,18 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 			if err != nil {
 				return ReportError(err)
 			}
-			fn := func(workspaceID resource.ID) (task.Spec, error) {
-				return m.Plans.Apply(workspaceID, createPlanOptions)
+			// Refuse upfront rather than silently skipping protected
+			// workspaces that forbid auto-apply.
+			for _, id := range ids {
+				if ws, err := m.Workspaces.Get(id); err == nil && ws.ForbidAutoApply {
+					return ReportError(fmt.Errorf("workspace %s is protected: auto-apply is forbidden, apply a plan instead", ws))
+				}
 			}
-			return YesNoPrompt(
+			return m.ConfirmAutoApply(
 				fmt.Sprintf(applyPrompt, len(ids)),
-				m.CreateTasks(fn, ids...),
+				ids,
+				createPlanOptions,
+				m.createTasks,
 			)
 		case key.Matches(msg, keys.Common.Cost):
 			ids, err := m.GetWorkspaceIDs()
@@ -121,7 +166,9 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 			if err != nil {
 				return ReportError(fmt.Errorf("creating task: %w", err))
 			}
-			return m.CreateTasksWithSpecs(spec)
+			return m.createTasksWithSpecs(spec)
+		case key.Matches(msg, keys.Common.Workflow):
+			return m.pickWorkflow(m.dryRun || m.DryRun)
 		case key.Matches(msg, keys.Common.State):
 			ids, err := m.GetWorkspaceIDs()
 			if err != nil {
@@ -143,7 +190,7 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 			if err != nil {
 				return ReportError(err)
 			}
-			return OpenEditor(m.Workdir.Join(mod.Path))
+			return OpenEditor(m.Workdir.Join(mod.Path), 0)
 		}
 	}
 	return nil
@@ -154,6 +201,7 @@ func (m *ActionHandler) HelpBindings() []key.Binding {
 		keys.Common.Init,
 		keys.Common.InitUpgrade,
 		keys.Common.Format,
+		keys.Common.FormatCheck,
 		keys.Common.Validate,
 		keys.Common.Plan,
 		keys.Common.PlanDestroy,
@@ -162,5 +210,77 @@ func (m *ActionHandler) HelpBindings() []key.Binding {
 		keys.Common.Execute,
 		keys.Common.State,
 		keys.Common.Cost,
+		keys.Common.Workflow,
+		keys.Common.WithArgs,
+		keys.Common.DryRun,
+	}
+}
+
+// createTasks creates tasks from the specs returned by invoking fn with each
+// id, first prompting the user to edit their args if so requested, and holding
+// the tasks if a dry-run is requested.
+func (m *ActionHandler) createTasks(fn task.SpecFunc, ids ...resource.ID) tea.Cmd {
+	if !m.withArgs {
+		if m.dryRun {
+			return m.CreateHeldTasks(fn, ids...)
+		}
+		return m.CreateTasks(fn, ids...)
 	}
+	create := m.specsCreator()
+	return func() tea.Msg {
+		specs := make([]task.Spec, 0, len(ids))
+		for _, id := range ids {
+			spec, err := fn(id)
+			if err != nil {
+				return ErrorMsg(fmt.Errorf("creating task: %w", err))
+			}
+			specs = append(specs, spec)
+		}
+		return m.promptArgs(create, specs...)()
+	}
+}
+
+// createTasksWithSpecs creates tasks from the specs, first prompting the user
+// to edit their args if so requested, and holding the tasks if a dry-run is
+// requested.
+func (m *ActionHandler) createTasksWithSpecs(specs ...task.Spec) tea.Cmd {
+	create := m.specsCreator()
+	if !m.withArgs {
+		return create(specs...)
+	}
+	return m.promptArgs(create, specs...)
+}
+
+// specsCreator returns the function with which to create tasks from specs,
+// which holds the tasks if a dry-run is requested.
+func (m *ActionHandler) specsCreator() func(...task.Spec) tea.Cmd {
+	if m.dryRun {
+		return m.CreateHeldTasksWithSpecs
+	}
+	return m.CreateTasksWithSpecs
+}
+
+// promptArgs prompts the user to edit the command that would be run for the
+// specs, and then creates tasks from the specs with the edited args.
+func (m *ActionHandler) promptArgs(create func(...task.Spec) tea.Cmd, specs ...task.Spec) tea.Cmd {
+	if len(specs) == 0 {
+		return nil
+	}
+	prompt := "Run: "
+	if len(specs) > 1 {
+		prompt = fmt.Sprintf("Run in %d directories: ", len(specs))
+	}
+	return CmdHandler(PromptMsg{
+		Prompt:       prompt,
+		InitialValue: m.Tasks.CommandLine(specs[0]),
+		Action: func(v string) tea.Cmd {
+			edited, err := m.Tasks.WithArgs(specs, v)
+			if err != nil {
+				return ReportError(err)
+			}
+			return create(edited...)
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
 }
@@ -19,14 +19,21 @@ func ReportInfo(msg string, args ...any) tea.Cmd {
 	return CmdHandler(InfoMsg(fmt.Sprintf(msg, args...)))
 }
 
-func OpenEditor(path string) tea.Cmd {
+// OpenEditor opens the path in the user's editor. If line is greater than zero
+// then the editor is instructed to open the file at that line, using the
+// `+<line>` argument understood by most editors.
+func OpenEditor(path string, line int) tea.Cmd {
 	// TODO: check for side effects of exec blocking the tui - do
 	// messages get queued up?
 	editor, ok := os.LookupEnv("EDITOR")
 	if !ok {
 		return ReportError(errors.New("cannot open editor: environment variable EDITOR not set"))
 	}
-	cmd := exec.Command(editor, path)
+	var args []string
+	if line > 0 {
+		args = append(args, fmt.Sprintf("+%d", line))
+	}
+	cmd := exec.Command(editor, append(args, path)...)
 	return tea.ExecProcess(cmd, func(err error) tea.Msg {
 		if err != nil {
 			return ReportError(fmt.Errorf("opening %s in editor: %w", path, err))()
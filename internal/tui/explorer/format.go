@@ -0,0 +1,154 @@
+package explorer
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+)
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(tui.Green)
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(tui.Red)
+	diffHunkStyle    = lipgloss.NewStyle().Foreground(tui.LightBlue)
+	diffHeaderStyle  = lipgloss.NewStyle().Bold(true)
+)
+
+// FormatDiffMaker makes models that show the changes formatting would make to
+// the modules that need formatting.
+type FormatDiffMaker struct {
+	Helpers *tui.Helpers
+}
+
+func (mm *FormatDiffMaker) Make(_ resource.ID, width, height int) (tui.ChildModel, error) {
+	m := &formatDiffModel{
+		Helpers: mm.Helpers,
+		viewport: tui.NewViewport(tui.ViewportOptions{
+			Width:  width,
+			Height: height,
+		}),
+	}
+	m.render()
+	return m, nil
+}
+
+type formatDiffModel struct {
+	*tui.Helpers
+
+	viewport tui.Viewport
+	// moduleIDs are the IDs of the modules that need formatting, excluding
+	// those beneath another such module, which formatting the latter
+	// recursively formats too.
+	moduleIDs []resource.ID
+}
+
+func (m *formatDiffModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *formatDiffModel) Update(msg tea.Msg) tea.Cmd {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Common.Format):
+			if len(m.moduleIDs) == 0 {
+				return tui.ReportInfo("No modules need formatting")
+			}
+			return tui.YesNoPrompt(
+				fmt.Sprintf("Format %d modules?", len(m.moduleIDs)),
+				m.CreateTasks(m.Modules.FormatRecursive, m.moduleIDs...),
+			)
+		}
+	case resource.Event[*module.Module]:
+		m.render()
+	case tea.WindowSizeMsg:
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+// render renders the diffs of the modules that need formatting.
+func (m *formatDiffModel) render() {
+	var (
+		b     strings.Builder
+		paths []string
+	)
+	m.moduleIDs = nil
+	mods := m.Modules.List()
+	slices.SortFunc(mods, module.ByPath)
+	for _, mod := range mods {
+		if len(mod.FormatDiffs) == 0 {
+			continue
+		}
+		beneath := slices.ContainsFunc(paths, func(path string) bool {
+			return path == "." || strings.HasPrefix(mod.Path, path+string(filepath.Separator))
+		})
+		if !beneath {
+			m.moduleIDs = append(m.moduleIDs, mod.ID)
+			paths = append(paths, mod.Path)
+		}
+		for _, diff := range mod.FormatDiffs {
+			b.WriteString(tui.ModulePathWithIcon(mod.Path, false))
+			b.WriteString(diffHeaderStyle.Render(" " + diff.Path))
+			b.WriteString("\n")
+			for _, line := range strings.Split(strings.TrimSuffix(diff.Diff, "\n"), "\n") {
+				b.WriteString(renderDiffLine(line))
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("No modules are known to need formatting. Press F on modules to check their formatting.")
+	}
+	m.viewport.SetContent([]byte(b.String()))
+}
+
+func renderDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		return diffHeaderStyle.Render(line)
+	case strings.HasPrefix(line, "@@"):
+		return diffHunkStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return diffAddedStyle.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return diffRemovedStyle.Render(line)
+	default:
+		return line
+	}
+}
+
+func (m *formatDiffModel) View() string {
+	return m.viewport.View()
+}
+
+func (m *formatDiffModel) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder:   tui.Bold.Render("format diff"),
+		tui.TopMiddleBorder: fmt.Sprintf("%d modules need formatting", len(m.moduleIDs)),
+	}
+}
+
+func (m *formatDiffModel) HelpBindings() []key.Binding {
+	return []key.Binding{keys.Common.Format}
+}
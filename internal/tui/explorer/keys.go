@@ -7,6 +7,8 @@ type keyMap struct {
 	SetCurrentWorkspace key.Binding
 	ReloadModules       key.Binding
 	ReloadWorkspaces    key.Binding
+	TerragruntConfig    key.Binding
+	ForceUnlock         key.Binding
 }
 
 var localKeys = keyMap{
@@ -26,4 +28,12 @@ var localKeys = keyMap{
 		key.WithKeys("ctrl+w"),
 		key.WithHelp("ctrl+w", "reload workspaces"),
 	),
+	TerragruntConfig: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "show terragrunt config"),
+	),
+	ForceUnlock: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "force-unlock state"),
+	),
 }
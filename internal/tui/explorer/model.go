@@ -124,6 +124,27 @@ func (m *model) Update(msg tea.Msg) tea.Cmd {
 				fmt.Sprintf("Delete workspace %s?", ws.name),
 				m.CreateTasks(m.Workspaces.Delete, ws.id),
 			)
+		case key.Matches(msg, localKeys.ForceUnlock):
+			node, ok := m.tracker.cursorNode.(workspaceNode)
+			if !ok {
+				return tui.ReportError(errors.New("cursor is not on a workspace"))
+			}
+			ws, err := m.Workspaces.Get(node.id)
+			if err != nil {
+				return tui.ReportError(err)
+			}
+			if ws.Lock == nil {
+				return tui.ReportError(errors.New("workspace state is not known to be locked"))
+			}
+			prompt := fmt.Sprintf("Force-unlock state of workspace %s, locked by %s (lock ID: %s)?", ws.Name, ws.Lock.Who, ws.Lock.ID)
+			if ws.Protected {
+				prompt += " The failed task is not retried on a protected workspace."
+			}
+			return tui.TypedConfirmPrompt(
+				prompt,
+				ws.Name,
+				m.CreateTasks(m.Workspaces.ForceUnlock, ws.ID),
+			)
 		case key.Matches(msg, localKeys.ReloadWorkspaces):
 			ids, err := m.GetModuleIDs()
 			if err != nil {
@@ -132,6 +153,23 @@ func (m *model) Update(msg tea.Msg) tea.Cmd {
 			return m.CreateTasks(m.Workspaces.Reload, ids...)
 		case key.Matches(msg, localKeys.ReloadModules):
 			return reload(false, m.Modules)
+		case key.Matches(msg, localKeys.TerragruntConfig):
+			node, ok := m.tracker.cursorNode.(moduleNode)
+			if !ok {
+				return tui.ReportError(errors.New("cursor is not on a module"))
+			}
+			mod, err := m.Modules.Get(node.id)
+			if err != nil {
+				return tui.ReportError(err)
+			}
+			if mod.Terragrunt == nil {
+				return tui.ReportError(errors.New("not a terragrunt module"))
+			}
+			summary := mod.Terragrunt.Summary()
+			if summary == "" {
+				summary = "no includes, dependencies or inputs found"
+			}
+			return tui.ReportInfo("%s: %s", mod.Path, summary)
 		default:
 			return m.common.Update(msg)
 		}
@@ -338,9 +376,18 @@ func (m model) GetModuleIDs() ([]resource.ID, error) {
 func (m model) HelpBindings() []key.Binding {
 	bindings := m.common.HelpBindings()
 	// Only show these help bindings when the cursor is on a workspace.
-	if _, ok := m.tracker.cursorNode.(workspaceNode); ok {
+	if node, ok := m.tracker.cursorNode.(workspaceNode); ok {
 		bindings = append(bindings, localKeys.SetCurrentWorkspace)
 		bindings = append(bindings, keys.Common.Delete)
+		if ws, err := m.Workspaces.Get(node.id); err == nil && ws.Lock != nil {
+			bindings = append(bindings, localKeys.ForceUnlock)
+		}
+	}
+	// Only show this help binding when the cursor is on a terragrunt module.
+	if node, ok := m.tracker.cursorNode.(moduleNode); ok {
+		if mod, err := m.Modules.Get(node.id); err == nil && mod.Terragrunt != nil {
+			bindings = append(bindings, localKeys.TerragruntConfig)
+		}
 	}
 	return bindings
 }
@@ -35,8 +35,14 @@ func (d dirNode) String() string {
 }
 
 type moduleNode struct {
-	id   resource.ID
-	path string
+	id      resource.ID
+	path    string
+	backend string
+	// dependencies is a comma-separated list of the names of the module's
+	// terragrunt dependencies.
+	dependencies string
+	// unformatted is the number of the module's files that need formatting.
+	unformatted int
 }
 
 func (m moduleNode) ID() any {
@@ -44,7 +50,24 @@ func (m moduleNode) ID() any {
 }
 
 func (m moduleNode) String() string {
-	return tui.ModulePathWithIcon(filepath.Base(m.path), false)
+	s := tui.ModulePathWithIcon(filepath.Base(m.path), false)
+	if m.backend != "" {
+		s += lipgloss.NewStyle().
+			Foreground(tui.LighterGrey).
+			Italic(true).
+			Render(fmt.Sprintf(" %s", m.backend))
+	}
+	if m.dependencies != "" {
+		s += lipgloss.NewStyle().
+			Foreground(tui.LighterGrey).
+			Render(fmt.Sprintf(" ⇠ %s", m.dependencies))
+	}
+	if m.unformatted > 0 {
+		s += lipgloss.NewStyle().
+			Foreground(tui.Yellow).
+			Render(fmt.Sprintf(" ≠ %d unformatted", m.unformatted))
+	}
+	return s
 }
 
 type workspaceNode struct {
@@ -53,6 +76,12 @@ type workspaceNode struct {
 	current       bool
 	resourceCount string
 	cost          string
+	// locked is true if a task failed because the workspace's state is
+	// locked.
+	locked bool
+	// protected is true if the workspace is protected from accidental
+	// changes.
+	protected bool
 }
 
 func (w workspaceNode) ID() any {
@@ -63,6 +92,9 @@ func (w workspaceNode) String() string {
 	name := lipgloss.NewStyle().
 		Render(w.name)
 	s := tui.WorkspaceNameWithIcon(name, false)
+	if w.protected {
+		s += " 🔒"
+	}
 	if w.current {
 		s += lipgloss.NewStyle().
 			Foreground(tui.LighterGrey).
@@ -80,5 +112,11 @@ func (w workspaceNode) String() string {
 			Italic(true).
 			Render(fmt.Sprintf(" %s", w.cost))
 	}
+	if w.locked {
+		s += lipgloss.NewStyle().
+			Foreground(tui.Red).
+			Bold(true).
+			Render(" locked")
+	}
 	return s
 }
@@ -59,6 +59,8 @@ func (b *treeBuilder) newTree(filter string) *tree {
 			current:       currentWorkspaces[ws.ID],
 			resourceCount: b.helpers.WorkspaceResourceCount(ws),
 			cost:          b.helpers.WorkspaceCost(ws),
+			locked:        ws.Lock != nil,
+			protected:     ws.Protected,
 		}
 		workspaceNodes[ws.ModuleID] = append(workspaceNodes[ws.ModuleID], wsNode)
 	}
@@ -70,10 +72,20 @@ func (b *treeBuilder) newTree(filter string) *tree {
 			parent = parent.addChild(dirNode{path: dir})
 		}
 		// The final node is the module tree, with workspaces as children.
-		modTree := parent.addChild(moduleNode{
-			id:   mod.ID,
-			path: mod.Path,
-		})
+		modNode := moduleNode{
+			id:          mod.ID,
+			path:        mod.Path,
+			backend:     mod.Backend,
+			unformatted: len(mod.FormatDiffs),
+		}
+		if mod.Terragrunt != nil {
+			deps := make([]string, len(mod.Terragrunt.Dependencies))
+			for i, dep := range mod.Terragrunt.Dependencies {
+				deps[i] = dep.String()
+			}
+			modNode.dependencies = strings.Join(deps, ",")
+		}
+		modTree := parent.addChild(modNode)
 		for _, ws := range workspaceNodes[mod.ID] {
 			modTree.addChild(ws)
 		}
@@ -3,7 +3,9 @@ package tui
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,6 +17,7 @@ import (
 	"github.com/leg100/pug/internal/resource"
 	"github.com/leg100/pug/internal/state"
 	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workflow"
 	"github.com/leg100/pug/internal/workspace"
 )
 
@@ -30,8 +33,12 @@ type Helpers struct {
 	Plans      *plan.Service
 	Tasks      *task.Service
 	States     *state.Service
+	Workflows  *workflow.Service
 	Logger     logging.Interface
 	Workdir    internal.Workdir
+	// DryRun is true if tasks created by the user are held for review rather
+	// than run.
+	DryRun bool
 }
 
 func (h *Helpers) ModuleCurrentWorkspace(mod *module.Module) *workspace.Workspace {
@@ -201,6 +208,10 @@ func WorkspaceNameWithIcon(name string, squareBrackets bool) string {
 func (h *Helpers) TaskStatus(t *task.Task, table bool) string {
 	var color lipgloss.Color
 
+	if t.Held && t.State == task.Pending {
+		return Regular.Foreground(Purple).Render("held")
+	}
+
 	switch t.State {
 	case task.Pending:
 		color = Grey
@@ -217,6 +228,34 @@ func (h *Helpers) TaskStatus(t *task.Task, table bool) string {
 	return Regular.Foreground(color).Render(string(t.State))
 }
 
+// TaskPriority renders the task's priority. An empty string is returned if the
+// task has normal priority.
+func (h *Helpers) TaskPriority(t *task.Task) string {
+	switch {
+	case t.Priority > task.NormalPriority:
+		return Regular.Foreground(Orange).Render(t.Priority.String())
+	case t.Priority < task.NormalPriority:
+		return Regular.Foreground(Grey).Render(t.Priority.String())
+	default:
+		return ""
+	}
+}
+
+// TaskAttempt renders the task's attempt number out of the maximum number of
+// attempts permitted by its retry policy. An empty string is returned if the
+// task is neither a retry nor has been retried.
+func (h *Helpers) TaskAttempt(t *task.Task) string {
+	if t.Attempt <= 1 && t.RetriedBy == nil && !t.Retrying {
+		return ""
+	}
+	s := fmt.Sprintf("%d/%d", t.Attempt, t.Retry.MaxAttempts)
+	if t.Retrying {
+		// Failed task awaiting retry.
+		s += " " + Regular.Foreground(Orange).Render("↻")
+	}
+	return s
+}
+
 // TaskSummary renders a summary of the task's outcome.
 func (h *Helpers) TaskSummary(t *task.Task, table bool) string {
 	if t.Summary == nil {
@@ -287,7 +326,7 @@ func (h *Helpers) GroupReport(group *task.Group, table bool) string {
 	}
 	slash := Regular.Inherit(inherit).Foreground(Grey).Render("/")
 	exited := Regular.Inherit(inherit).Foreground(Green).Render(fmt.Sprintf("%d", group.Exited()))
-	total := Regular.Inherit(inherit).Foreground(Blue).Render(fmt.Sprintf("%d", len(group.Tasks)))
+	total := Regular.Inherit(inherit).Foreground(Blue).Render(fmt.Sprintf("%d", group.Len()))
 
 	s := fmt.Sprintf("%s%s%s", exited, slash, total)
 	if errored := group.Errored(); errored > 0 {
@@ -300,11 +339,194 @@ func (h *Helpers) GroupReport(group *task.Group, table bool) string {
 	return Padded.Background(GroupReportBackgroundColor).Render(s)
 }
 
+// ConfirmChanges prompts the user to confirm changes to the workspaces before
+// invoking the action. If any of the workspaces are protected then the user
+// must type them, in the form <module path>:<workspace>, to confirm, because
+// workspace names alone are rarely unique, e.g. prod; otherwise a yes or no
+// suffices.
+func (h *Helpers) ConfirmChanges(prompt string, workspaceIDs []resource.ID, action tea.Cmd) tea.Cmd {
+	var protected []string
+	for _, id := range workspaceIDs {
+		ws, err := h.Workspaces.Get(id)
+		if err != nil || !ws.Protected {
+			continue
+		}
+		if name := ws.ModulePath + ":" + ws.Name; !slices.Contains(protected, name) {
+			protected = append(protected, name)
+		}
+	}
+	if len(protected) == 0 {
+		return YesNoPrompt(prompt, action)
+	}
+	slices.Sort(protected)
+	return TypedConfirmPrompt(prompt, strings.Join(protected, ","), action)
+}
+
+// ConfirmAutoApply prompts the user to confirm auto-applying the workspaces
+// before invoking create to create tasks to auto-apply them with the given
+// options. An auto-apply cannot be checked against policies, so if any
+// policies apply to the workspaces then the user must first type 'override' to
+// override the policies.
+func (h *Helpers) ConfirmAutoApply(prompt string, workspaceIDs []resource.ID, opts plan.CreateOptions, create func(task.SpecFunc, ...resource.ID) tea.Cmd) tea.Cmd {
+	var policies []string
+	for _, id := range workspaceIDs {
+		applicable, err := h.Plans.Policies(id)
+		if err != nil {
+			continue
+		}
+		for _, policy := range applicable {
+			if !slices.Contains(policies, policy.Name) {
+				policies = append(policies, policy.Name)
+			}
+		}
+	}
+	opts.OverridePolicies = len(policies) > 0
+	fn := func(workspaceID resource.ID) (task.Spec, error) {
+		return h.Plans.Apply(workspaceID, opts)
+	}
+	if len(policies) == 0 {
+		return h.ConfirmChanges(prompt, workspaceIDs, create(fn, workspaceIDs...))
+	}
+	return TypedConfirmPrompt(
+		fmt.Sprintf("Auto-apply cannot be checked against policies: %s.", strings.Join(policies, ", ")),
+		"override",
+		h.ConfirmChanges(prompt, workspaceIDs, create(fn, workspaceIDs...)),
+	)
+}
+
+// RetryTasks prompts the user to confirm retrying the tasks before creating
+// new tasks from their specs. See ConfirmRetry.
+func (h *Helpers) RetryTasks(prompt string, tasks ...*task.Task) tea.Cmd {
+	specs := make([]task.Spec, len(tasks))
+	for i, t := range tasks {
+		specs[i] = t.Spec
+	}
+	return h.ConfirmRetry(prompt, tasks, h.CreateTasksWithSpecs(specs...))
+}
+
+// ConfirmRetry prompts the user to confirm retrying the tasks before invoking
+// the action. Retries are confirmed in the same way as the original tasks:
+// applying a protected workspace, or removing resources from its state,
+// requires typed confirmation; auto-applying a protected workspace that forbids
+// it is refused; and auto-applying a workspace to which policies apply requires
+// the user to first type 'override'.
+func (h *Helpers) ConfirmRetry(prompt string, tasks []*task.Task, action tea.Cmd) tea.Cmd {
+	var (
+		changes  []resource.ID
+		policies []string
+	)
+	for _, t := range tasks {
+		if t.WorkspaceID == nil {
+			continue
+		}
+		switch t.Identifier {
+		case plan.ApplyTask, state.DeleteTask:
+			changes = append(changes, *t.WorkspaceID)
+		}
+		if !plan.AutoApplies(t.Spec) {
+			continue
+		}
+		ws, err := h.Workspaces.Get(*t.WorkspaceID)
+		if err != nil {
+			return ReportError(fmt.Errorf("retrying task: %w", err))
+		}
+		if ws.ForbidAutoApply {
+			return ReportError(fmt.Errorf("workspace %s is protected: auto-apply is forbidden, apply a plan instead", ws))
+		}
+		applicable, err := h.Plans.Policies(ws.ID)
+		if err != nil {
+			continue
+		}
+		for _, policy := range applicable {
+			if !slices.Contains(policies, policy.Name) {
+				policies = append(policies, policy.Name)
+			}
+		}
+	}
+	confirm := h.ConfirmChanges(prompt, changes, action)
+	if len(policies) == 0 {
+		return confirm
+	}
+	return TypedConfirmPrompt(
+		fmt.Sprintf("Auto-apply cannot be checked against policies: %s.", strings.Join(policies, ", ")),
+		"override",
+		confirm,
+	)
+}
+
+// ApplyPlans prompts the user to confirm applying the plans created by the
+// plan tasks with the given IDs, before creating tasks to apply them. If any of
+// the plans are stale then the user is instead offered to re-plan them. And if
+// any of the plans violate policies then the user must first type 'override'
+// to override the policies.
+//
+// Checking whether plans are stale retrieves state, so it is done in the
+// returned command rather than upfront, and only once: the plans are not
+// checked again upon confirmation.
+func (h *Helpers) ApplyPlans(prompt string, taskIDs ...resource.ID) tea.Cmd {
+	return func() tea.Msg {
+		var (
+			workspaceIDs []resource.ID
+			stale        []resource.ID
+			staleErr     error
+			violations   []string
+		)
+		for i, err := range h.Plans.CheckPlans(taskIDs...) {
+			id := taskIDs[i]
+			if t, err := h.Tasks.Get(id); err == nil && t.WorkspaceID != nil {
+				workspaceIDs = append(workspaceIDs, *t.WorkspaceID)
+			}
+			switch {
+			case errors.Is(err, plan.ErrStalePlan):
+				stale = append(stale, id)
+				staleErr = err
+			case errors.Is(err, plan.ErrPolicyViolation):
+				results, _ := h.Plans.PolicyResults(id)
+				for _, name := range plan.Violations(results) {
+					if !slices.Contains(violations, name) {
+						violations = append(violations, name)
+					}
+				}
+			case err != nil:
+				return ErrorMsg(fmt.Errorf("applying plan: %w", err))
+			}
+		}
+		apply := func(overridePolicies bool) task.SpecFunc {
+			return func(id resource.ID) (task.Spec, error) {
+				return h.Plans.ApplyCheckedPlan(id, overridePolicies)
+			}
+		}
+		switch {
+		case len(taskIDs) == 1 && staleErr != nil:
+			return YesNoPrompt(
+				fmt.Sprintf("%s. Re-plan?", staleErr),
+				h.CreateTasks(h.Plans.Replan, stale...),
+			)()
+		case len(stale) > 0:
+			return YesNoPrompt(
+				fmt.Sprintf("%d of %d plans are stale: state has changed since planning. Re-plan them?", len(stale), len(taskIDs)),
+				h.CreateTasks(h.Plans.Replan, stale...),
+			)()
+		case len(violations) > 0:
+			return TypedConfirmPrompt(
+				fmt.Sprintf("Policies violated: %s.", strings.Join(violations, ", ")),
+				"override",
+				h.ConfirmChanges(prompt, workspaceIDs, h.CreateTasks(apply(true), taskIDs...)),
+			)()
+		default:
+			return h.ConfirmChanges(prompt, workspaceIDs, h.CreateTasks(apply(false), taskIDs...))()
+		}
+	}
+}
+
 // CreateTasks repeatedly invokes fn with each id in ids, creating a task for
 // each invocation. If there is more than one id then a task group is created
 // and the user sent to the task group's page; otherwise if only id is provided,
 // the user is sent to the task's page.
 func (h *Helpers) CreateTasks(fn task.SpecFunc, ids ...resource.ID) tea.Cmd {
+	if h.DryRun {
+		return h.CreateHeldTasks(fn, ids...)
+	}
 	return func() tea.Msg {
 		switch len(ids) {
 		case 0:
@@ -339,6 +561,9 @@ func (h *Helpers) CreateTasks(fn task.SpecFunc, ids ...resource.ID) tea.Cmd {
 }
 
 func (h *Helpers) CreateTasksWithSpecs(specs ...task.Spec) tea.Cmd {
+	if h.DryRun {
+		return h.CreateHeldTasksWithSpecs(specs...)
+	}
 	return func() tea.Msg {
 		switch len(specs) {
 		case 0:
@@ -355,6 +580,45 @@ func (h *Helpers) CreateTasksWithSpecs(specs ...task.Spec) tea.Cmd {
 	}
 }
 
+// CreateHeldTasks is like CreateTasks but the tasks are held in a task group
+// rather than run, i.e. a dry-run, and the user is sent to the group's
+// execution plan for review.
+func (h *Helpers) CreateHeldTasks(fn task.SpecFunc, ids ...resource.ID) tea.Cmd {
+	return func() tea.Msg {
+		specs := make([]task.Spec, 0, len(ids))
+		for _, id := range ids {
+			spec, err := fn(id)
+			if err != nil {
+				h.Logger.Error("creating task spec", "error", err, "id", id)
+				continue
+			}
+			specs = append(specs, spec)
+		}
+		return h.CreateHeldTasksWithSpecs(specs...)()
+	}
+}
+
+// CreateHeldTasksWithSpecs is like CreateTasksWithSpecs but the tasks are held
+// in a task group rather than run, i.e. a dry-run, and the user is sent to the
+// group's execution plan for review.
+func (h *Helpers) CreateHeldTasksWithSpecs(specs ...task.Spec) tea.Cmd {
+	return func() tea.Msg {
+		if len(specs) == 0 {
+			return nil
+		}
+		held := make([]task.Spec, len(specs))
+		for i, spec := range specs {
+			spec.Held = true
+			held[i] = spec
+		}
+		group, err := h.Tasks.CreateGroup(held...)
+		if err != nil {
+			return ErrorMsg(fmt.Errorf("creating task group: %w", err))
+		}
+		return NewNavigationMsg(ExecutionPlanKind, WithParent(group.ID))
+	}
+}
+
 func (h *Helpers) createTaskGroup(specs ...task.Spec) tea.Msg {
 	group, err := h.Tasks.CreateGroup(specs...)
 	if err != nil {
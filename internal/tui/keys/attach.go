@@ -0,0 +1,18 @@
+package keys
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type attach struct {
+	Detach key.Binding
+}
+
+// Attach is a key map of keys available in attach mode. All other keys are
+// forwarded to the attached task.
+var Attach = attach{
+	Detach: key.NewBinding(
+		key.WithKeys("ctrl+]"),
+		key.WithHelp("ctrl+]", "detach"),
+	),
+}
@@ -18,7 +18,11 @@ type common struct {
 	InitUpgrade key.Binding
 	Validate    key.Binding
 	Format      key.Binding
+	FormatCheck key.Binding
 	Cost        key.Binding
+	Workflow    key.Binding
+	WithArgs    key.Binding
+	DryRun      key.Binding
 	LastTask    key.Binding
 	Back        key.Binding
 }
@@ -85,10 +89,26 @@ var Common = common{
 		key.WithKeys("f"),
 		key.WithHelp("f", "format"),
 	),
+	FormatCheck: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "format check"),
+	),
 	Cost: key.NewBinding(
 		key.WithKeys("$"),
 		key.WithHelp("$", "cost"),
 	),
+	Workflow: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "run workflow"),
+	),
+	WithArgs: key.NewBinding(
+		key.WithKeys(":"),
+		key.WithHelp(":", "run with args"),
+	),
+	DryRun: key.NewBinding(
+		key.WithKeys("~"),
+		key.WithHelp("~", "dry-run"),
+	),
 	LastTask: key.NewBinding(
 		key.WithKeys("o"),
 		key.WithHelp("o", "last task output"),
@@ -9,6 +9,7 @@ type global struct {
 	Tasks            key.Binding
 	TaskGroups       key.Binding
 	Logs             key.Binding
+	Problems         key.Binding
 	Select           key.Binding
 	SelectAll        key.Binding
 	SelectClear      key.Binding
@@ -20,6 +21,8 @@ type global struct {
 	GrowPaneWidth    key.Binding
 	ClosePane        key.Binding
 	Autoscroll       key.Binding
+	PauseQueue       key.Binding
+	DryRun           key.Binding
 	Quit             key.Binding
 	Suspend          key.Binding
 	Help             key.Binding
@@ -42,6 +45,10 @@ var Global = global{
 		key.WithKeys("l"),
 		key.WithHelp("l", "logs"),
 	),
+	Problems: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "problems"),
+	),
 	Select: key.NewBinding(
 		key.WithKeys(" "),
 		key.WithHelp("<space>", "select"),
@@ -86,6 +93,14 @@ var Global = global{
 		key.WithKeys("ctrl+s"),
 		key.WithHelp("ctrl+s", "toggle autoscroll"),
 	),
+	PauseQueue: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "pause/resume queue"),
+	),
+	DryRun: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "toggle dry-run mode"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c"),
 		key.WithHelp("ctrl+c", "exit"),
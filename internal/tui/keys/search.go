@@ -0,0 +1,27 @@
+package keys
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type search struct {
+	NextMatch  key.Binding
+	PrevMatch  key.Binding
+	FirstError key.Binding
+}
+
+// Search is a key map of keys for searching output.
+var Search = search{
+	NextMatch: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next match"),
+	),
+	PrevMatch: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "previous match"),
+	),
+	FirstError: key.NewBinding(
+		key.WithKeys("ctrl+e"),
+		key.WithHelp("ctrl+e", "go to first error"),
+	),
+}
@@ -14,4 +14,7 @@ const (
 	LogListKind
 	LogKind
 	ExplorerKind
+	ProblemListKind
+	FormatDiffKind
+	ExecutionPlanKind
 )
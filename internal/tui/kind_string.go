@@ -17,11 +17,14 @@ func _() {
 	_ = x[LogListKind-6]
 	_ = x[LogKind-7]
 	_ = x[ExplorerKind-8]
+	_ = x[ProblemListKind-9]
+	_ = x[FormatDiffKind-10]
+	_ = x[ExecutionPlanKind-11]
 }
 
-const _Kind_name = "TaskListKindTaskKindTaskGroupListKindTaskGroupKindResourceListKindResourceKindLogListKindLogKindExplorerKind"
+const _Kind_name = "TaskListKindTaskKindTaskGroupListKindTaskGroupKindResourceListKindResourceKindLogListKindLogKindExplorerKindProblemListKindFormatDiffKindExecutionPlanKind"
 
-var _Kind_index = [...]uint8{0, 12, 20, 37, 50, 66, 78, 89, 96, 108}
+var _Kind_index = [...]uint8{0, 12, 20, 37, 50, 66, 78, 89, 96, 108, 123, 137, 154}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
@@ -3,6 +3,7 @@ package tui
 import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
 )
 
 // NavigationMsg is an instruction to navigate to a page.
@@ -55,3 +56,9 @@ type FilterCloseMsg struct{}
 
 // FilterKeyMsg is a key entered by the user into the filter widget
 type FilterKeyMsg tea.KeyMsg
+
+// AttachMsg is a request to attach to a task running in a pseudo-terminal,
+// forwarding keys to the task's program until detached.
+type AttachMsg struct {
+	Task *task.Task
+}
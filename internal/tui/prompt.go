@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -27,6 +28,9 @@ type PromptMsg struct {
 	CancelAnyOther bool
 	// Set placeholder text in prompt
 	Placeholder string
+	// Suggestions are values suggested to the user as they type, which can be
+	// accepted by pressing tab.
+	Suggestions []string
 }
 
 type PromptAction func(text string) tea.Cmd
@@ -48,12 +52,33 @@ func YesNoPrompt(prompt string, action tea.Cmd) tea.Cmd {
 	})
 }
 
+// TypedConfirmPrompt sends a message to enable the prompt widget, asking the
+// user to type the given confirmation text. The action is only invoked if the
+// text typed matches.
+func TypedConfirmPrompt(prompt, confirmation string, action tea.Cmd) tea.Cmd {
+	return CmdHandler(PromptMsg{
+		Prompt: fmt.Sprintf("%s Type '%s' to confirm: ", prompt, confirmation),
+		Action: func(v string) tea.Cmd {
+			if v != confirmation {
+				return ReportError(errors.New("confirmation did not match: canceled operation"))
+			}
+			return action
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
+
 func NewPrompt(msg PromptMsg) (*Prompt, tea.Cmd) {
 	model := textinput.New()
 	model.Prompt = msg.Prompt
 	model.SetValue(msg.InitialValue)
 	model.Placeholder = msg.Placeholder
 	model.PlaceholderStyle = lipgloss.NewStyle().Faint(true)
+	if len(msg.Suggestions) > 0 {
+		model.ShowSuggestions = true
+		model.SetSuggestions(msg.Suggestions)
+	}
 	blink := model.Focus()
 
 	prompt := Prompt{
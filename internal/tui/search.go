@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	matchStyle        = lipgloss.NewStyle().Background(Yellow).Foreground(Black)
+	currentMatchStyle = lipgloss.NewStyle().Background(Orange).Foreground(Black).Bold(true)
+
+	// errorDiagnostic matches the first line of a terraform error diagnostic,
+	// which is usually drawn inside a box, e.g.:
+	//
+	//	╷
+	//	│ Error: Invalid reference
+	//	│
+	//	│   on main.tf line 1:
+	//	╵
+	errorDiagnostic = regexp.MustCompile(`^[│|]?\s*Error: `)
+)
+
+// match is a match of a search pattern within a line of content.
+type match struct {
+	line int
+	// start and end are the byte offsets of the match within the line, with
+	// ANSI escape codes stripped.
+	start, end int
+}
+
+// compileSearch compiles a search pattern into a regular expression. The
+// search is case-insensitive unless the pattern contains an upper case
+// letter.
+func compileSearch(pattern string) (*regexp.Regexp, error) {
+	if !strings.ContainsFunc(pattern, unicode.IsUpper) {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// findMatches finds all non-empty matches of the regular expression within
+// the lines.
+func findMatches(re *regexp.Regexp, lines []string) []match {
+	var matches []match
+	for i, line := range lines {
+		for _, loc := range re.FindAllStringIndex(ansi.Strip(line), -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			matches = append(matches, match{line: i, start: loc[0], end: loc[1]})
+		}
+	}
+	return matches
+}
+
+// highlightMatches returns a copy of the lines with the matches highlighted,
+// with the current match highlighted differently. Lines with matches lose
+// their ANSI escape codes.
+func highlightMatches(lines []string, matches []match, current int) []string {
+	highlighted := make([]string, len(lines))
+	copy(highlighted, lines)
+	for i := 0; i < len(matches); {
+		var (
+			line  = matches[i].line
+			plain = ansi.Strip(lines[line])
+			b     strings.Builder
+			last  int
+		)
+		for ; i < len(matches) && matches[i].line == line; i++ {
+			style := matchStyle
+			if i == current {
+				style = currentMatchStyle
+			}
+			b.WriteString(plain[last:matches[i].start])
+			b.WriteString(style.Render(plain[matches[i].start:matches[i].end]))
+			last = matches[i].end
+		}
+		b.WriteString(plain[last:])
+		highlighted[line] = b.String()
+	}
+	return highlighted
+}
+
+// firstError returns the line at which the first terraform error diagnostic
+// starts, including the top of its box if it has one. False is returned if
+// there are no errors.
+func firstError(lines []string) (int, bool) {
+	for i, line := range lines {
+		if errorDiagnostic.MatchString(ansi.Strip(line)) {
+			if i > 0 && strings.TrimSpace(ansi.Strip(lines[i-1])) == "╷" {
+				return i - 1, true
+			}
+			return i, true
+		}
+	}
+	return 0, false
+}
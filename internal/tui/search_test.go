@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		input   string
+		want    bool
+	}{
+		{"lower case is case-insensitive", "error", "Error: foo", true},
+		{"upper case is case-sensitive", "Error", "error: foo", false},
+		{"regex", "aws_[a-z]+\\.web", "aws_instance.web", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := compileSearch(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, re.MatchString(tt.input))
+		})
+	}
+
+	_, err := compileSearch("aws_[")
+	assert.Error(t, err)
+}
+
+func TestFindMatches(t *testing.T) {
+	lines := []string{
+		"\x1b[1mfoo\x1b[0m bar foo",
+		"bar",
+		"foo",
+	}
+	re, err := compileSearch("foo")
+	require.NoError(t, err)
+
+	got := findMatches(re, lines)
+	want := []match{
+		{line: 0, start: 0, end: 3},
+		{line: 0, start: 8, end: 11},
+		{line: 2, start: 0, end: 3},
+	}
+	assert.Equal(t, want, got)
+
+	// Empty matches are skipped
+	re, err = compileSearch("x*")
+	require.NoError(t, err)
+	assert.Empty(t, findMatches(re, lines))
+
+	// Matches are highlighted without altering the text.
+	highlighted := highlightMatches(lines, got, 1)
+	for i := range lines {
+		assert.Equal(t, ansi.Strip(lines[i]), ansi.Strip(highlighted[i]))
+	}
+}
+
+func TestFirstError(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		want   int
+		wantOK bool
+	}{
+		{
+			"diagnostic box",
+			[]string{
+				"Planning...",
+				"╷",
+				"│ Error: Invalid reference",
+				"│ ",
+				"╵",
+			},
+			1,
+			true,
+		},
+		{
+			"no box",
+			[]string{"Planning...", "\x1b[31mError: \x1b[0mInvalid reference"},
+			1,
+			true,
+		},
+		{
+			"no errors",
+			[]string{"Planning...", "No changes. Your infrastructure matches the configuration."},
+			0,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := firstError(tt.lines)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestViewport_Search(t *testing.T) {
+	m := NewViewport(ViewportOptions{Width: 80, Height: 10})
+
+	var content strings.Builder
+	for i := range 100 {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	require.NoError(t, m.AppendContent([]byte(content.String()), true, false))
+
+	m, _ = m.Update(FilterFocusReqMsg{})
+	assert.True(t, m.Searching())
+	for _, r := range "line 5" {
+		m, _ = m.Update(FilterKeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	// Matches "line 5" and "line 50" to "line 59"
+	assert.Len(t, m.matches, 11)
+	assert.Equal(t, 0, m.current)
+	assert.Contains(t, m.searchView(), "1/11")
+
+	// Navigate to next match, scrolling it into view.
+	m, _ = m.Update(FilterBlurMsg{})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	assert.Equal(t, 1, m.current)
+	assert.Equal(t, 50, m.matches[m.current].line)
+	assert.LessOrEqual(t, m.viewport.YOffset, 50)
+	assert.Greater(t, m.viewport.YOffset+m.viewport.Height, 50)
+
+	// Navigating backwards from the first match wraps around to the last.
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'N'}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'N'}})
+	assert.Equal(t, 10, m.current)
+
+	// Closing the search clears the matches.
+	m, _ = m.Update(FilterCloseMsg{})
+	assert.False(t, m.Searching())
+	assert.Empty(t, m.matches)
+}
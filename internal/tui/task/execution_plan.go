@@ -0,0 +1,222 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+)
+
+var executionPlanLabelStyle = lipgloss.NewStyle().Foreground(tui.LighterGrey)
+
+// ExecutionPlanMaker makes models that show the execution plan of a task
+// group: the tasks the group would run, and the order in which they would
+// run. Its purpose is to review a group of held tasks, i.e. a dry-run, before
+// running them for real.
+type ExecutionPlanMaker struct {
+	Tasks   *task.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *ExecutionPlanMaker) Make(groupID resource.ID, width, height int) (tui.ChildModel, error) {
+	group, err := mm.Tasks.GetGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	m := &executionPlanModel{
+		Helpers: mm.Helpers,
+		group:   group,
+		viewport: tui.NewViewport(tui.ViewportOptions{
+			Width:  width,
+			Height: height,
+		}),
+	}
+	m.render()
+	return m, nil
+}
+
+type executionPlanModel struct {
+	*tui.Helpers
+
+	group    *task.Group
+	viewport tui.Viewport
+}
+
+func (m *executionPlanModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *executionPlanModel) Update(msg tea.Msg) tea.Cmd {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, executionPlanKeys.Run):
+			return m.run()
+		case key.Matches(msg, groupKeys.CancelRemaining):
+			return cancelRemaining(m.Tasks, m.group)
+		}
+	case resource.Event[*task.Task]:
+		if m.group.IncludesTask(msg.Payload.ID) {
+			m.render()
+		}
+	case tea.WindowSizeMsg:
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+// run releases the group's held tasks, sending the user to the group's page.
+func (m *executionPlanModel) run() tea.Cmd {
+	held := m.group.Held()
+	if len(held) == 0 {
+		return tui.ReportError(errors.New("task group has no held tasks"))
+	}
+	// Applying protected workspaces, or removing resources from their state,
+	// requires typed confirmation.
+	var changes []resource.ID
+	for _, t := range held {
+		switch t.Identifier {
+		case plan.ApplyTask, state.DeleteTask:
+			if t.WorkspaceID != nil {
+				changes = append(changes, *t.WorkspaceID)
+			}
+		}
+	}
+	return m.ConfirmChanges(
+		fmt.Sprintf("Run %d tasks?", len(held)),
+		changes,
+		func() tea.Msg {
+			if _, err := m.Tasks.ReleaseGroup(m.group.ID); err != nil {
+				return tui.ErrorMsg(fmt.Errorf("running task group: %w", err))
+			}
+			return tui.NewNavigationMsg(tui.TaskGroupKind, tui.WithParent(m.group.ID))
+		},
+	)
+}
+
+// render renders the group's tasks as a tree, with each task beneath the task
+// it depends upon. A task that depends upon several tasks is rendered beneath
+// the first of them.
+func (m *executionPlanModel) render() {
+	var (
+		roots    []*task.Task
+		children = make(map[resource.ID][]*task.Task)
+	)
+	for _, t := range m.group.Tasks {
+		var parent *resource.ID
+		for _, id := range t.DependsOn {
+			if m.group.IncludesTask(id) {
+				parent = &id
+				break
+			}
+		}
+		if parent == nil {
+			roots = append(roots, t)
+		} else {
+			children[*parent] = append(children[*parent], t)
+		}
+	}
+	var b strings.Builder
+	var renderTask func(t *task.Task, prefix string, root, last bool)
+	renderTask = func(t *task.Task, prefix string, root, last bool) {
+		// indent is the prefix of lines beneath the task's first line.
+		indent := prefix
+		if !root {
+			connector := "├─ "
+			indent += "│  "
+			if last {
+				connector = "└─ "
+				indent = prefix + "   "
+			}
+			prefix += connector
+		}
+		fmt.Fprintf(&b, "%s%s %s %s %s %s\n",
+			prefix,
+			tui.Bold.Render(t.ID.String()),
+			t.String(),
+			m.TaskModulePathWithIcon(t),
+			m.TaskWorkspaceNameWithIcon(t),
+			m.TaskStatus(t, false),
+		)
+		details := [][2]string{
+			{"path", t.Path},
+			{"command", strings.Join(append([]string{t.Program}, t.Args...), " ")},
+		}
+		if t.AdditionalExecution != nil {
+			details = append(details, [2]string{"then", strings.Join(append([]string{t.AdditionalExecution.Program}, t.AdditionalExecution.Args...), " ")})
+		}
+		if len(t.AdditionalEnv) > 0 {
+			details = append(details, [2]string{"env", strings.Join(t.AdditionalEnv, " ")})
+		}
+		if len(t.DependsOn) > 0 {
+			ids := make([]string, len(t.DependsOn))
+			for i, id := range t.DependsOn {
+				ids[i] = id.String()
+			}
+			details = append(details, [2]string{"after", strings.Join(ids, ", ")})
+		}
+		// Indent details beneath the task's children's connectors.
+		detailIndent := indent
+		if len(children[t.ID]) > 0 {
+			detailIndent += "│ "
+		} else {
+			detailIndent += "  "
+		}
+		for _, detail := range details {
+			fmt.Fprintf(&b, "%s%s %s\n", detailIndent, executionPlanLabelStyle.Render(detail[0]+":"), detail[1])
+		}
+		for i, child := range children[t.ID] {
+			renderTask(child, indent, false, i == len(children[t.ID])-1)
+		}
+	}
+	for _, t := range roots {
+		renderTask(t, "", true, true)
+		b.WriteString("\n")
+	}
+	m.viewport.SetContent([]byte(b.String()))
+}
+
+func (m *executionPlanModel) View() string {
+	return m.viewport.View()
+}
+
+func (m *executionPlanModel) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s %s",
+			tui.Bold.Render("execution plan"),
+			m.group.String(),
+		),
+		tui.TopMiddleBorder: fmt.Sprintf("%d tasks held", len(m.group.Held())),
+	}
+}
+
+func (m *executionPlanModel) HelpBindings() []key.Binding {
+	var bindings []key.Binding
+	if len(m.group.Held()) > 0 {
+		bindings = append(bindings, executionPlanKeys.Run)
+	}
+	if len(m.group.Remaining()) > 0 {
+		bindings = append(bindings, groupKeys.CancelRemaining)
+	}
+	return bindings
+}
@@ -0,0 +1,34 @@
+package task
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+)
+
+// export prompts the user for a directory or tarball to which to export the
+// output of the tasks, suggesting the initial value.
+func export(tasks *task.Service, initial string, taskIDs ...resource.ID) tea.Cmd {
+	return tui.CmdHandler(tui.PromptMsg{
+		Prompt:       fmt.Sprintf("Export output of %d tasks to directory or tarball: ", len(taskIDs)),
+		InitialValue: initial,
+		Placeholder:  "directory, or file ending with .tar.gz",
+		Action: func(v string) tea.Cmd {
+			if v == "" {
+				return nil
+			}
+			return func() tea.Msg {
+				if err := tasks.Export(v, taskIDs...); err != nil {
+					return tui.ErrorMsg(fmt.Errorf("exporting task output: %w", err))
+				}
+				return tui.InfoMsg(fmt.Sprintf("exported output of %d tasks to %s", len(taskIDs), v))
+			}
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
@@ -1,8 +1,10 @@
 package task
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/leg100/pug/internal/plan"
 	"github.com/leg100/pug/internal/resource"
@@ -89,6 +91,21 @@ func (m *groupModel) Update(msg tea.Msg) tea.Cmd {
 		if m.skip(msg.Payload) {
 			return nil
 		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, groupKeys.CancelRemaining):
+			return cancelRemaining(m.Tasks, m.group)
+		case key.Matches(msg, groupKeys.ExecutionPlan):
+			return tui.NavigateTo(tui.ExecutionPlanKind, tui.WithParent(m.group.ID))
+		case key.Matches(msg, groupKeys.RetryFailed):
+			return m.retryFailed()
+		case key.Matches(msg, localKeys.Export):
+			ids := make([]resource.ID, len(m.group.Tasks))
+			for i, t := range m.group.Tasks {
+				ids[i] = t.ID
+			}
+			return export(m.Tasks, fmt.Sprintf("group-%d.tar.gz", m.group.ID.Serial), ids...)
+		}
 	}
 
 	// Forward message to wrapped task list model
@@ -109,12 +126,62 @@ func (m *groupModel) skip(tasks ...*task.Task) bool {
 	return false
 }
 
+// cancelRemaining cancels the group's remaining tasks.
+func cancelRemaining(tasks *task.Service, group *task.Group) tea.Cmd {
+	remaining := len(group.Remaining())
+	if remaining == 0 {
+		return tui.ReportError(errors.New("task group has no remaining tasks"))
+	}
+	return tui.YesNoPrompt(
+		fmt.Sprintf("Cancel %d remaining tasks?", remaining),
+		func() tea.Msg {
+			canceled, err := tasks.CancelGroup(group.ID)
+			if err != nil {
+				return tui.ErrorMsg(fmt.Errorf("canceling task group: %w", err))
+			}
+			return tui.InfoMsg(fmt.Sprintf("sent cancel signal to %d tasks", len(canceled)))
+		},
+	)
+}
+
+// retryFailed retries the group's failed and canceled tasks as a new group.
+func (m groupModel) retryFailed() tea.Cmd {
+	failed := m.group.Failed()
+	if len(failed) == 0 {
+		return tui.ReportError(errors.New("task group has no failed or canceled tasks"))
+	}
+	return m.ConfirmRetry(
+		fmt.Sprintf("Retry %d failed tasks?", len(failed)),
+		failed,
+		func() tea.Msg {
+			group, err := m.Tasks.RetryGroup(m.group.ID)
+			if err != nil {
+				return tui.ErrorMsg(fmt.Errorf("retrying task group: %w", err))
+			}
+			return tui.NewNavigationMsg(tui.TaskGroupKind, tui.WithParent(group.ID))
+		},
+	)
+}
+
+func (m groupModel) HelpBindings() []key.Binding {
+	bindings := m.List.HelpBindings()
+	bindings = append(bindings, localKeys.Export, groupKeys.ExecutionPlan)
+	if len(m.group.Remaining()) > 0 {
+		bindings = append(bindings, groupKeys.CancelRemaining)
+	}
+	if len(m.group.Failed()) > 0 {
+		bindings = append(bindings, groupKeys.RetryFailed)
+	}
+	return bindings
+}
+
 func (m groupModel) BorderText() map[tui.BorderPosition]string {
 	return map[tui.BorderPosition]string{
 		tui.TopLeftBorder: fmt.Sprintf(
-			"%s %s",
+			"%s %s %s",
 			tui.Bold.Render(m.group.String()),
 			m.GroupReport(m.group, true),
+			tui.Regular.Foreground(tui.LighterGrey).Render(string(m.group.Policy)),
 		),
 		tui.TopMiddleBorder: m.Metadata(),
 	}
@@ -6,6 +6,10 @@ type keyMap struct {
 	ToggleInfo key.Binding
 	Enter      key.Binding
 	ApplyPlan  key.Binding
+	Bump       key.Binding
+	Demote     key.Binding
+	Attach     key.Binding
+	Export     key.Binding
 }
 
 var localKeys = keyMap{
@@ -21,6 +25,22 @@ var localKeys = keyMap{
 		key.WithKeys("a"),
 		key.WithHelp("a", "apply plan"),
 	),
+	Bump: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "bump priority"),
+	),
+	Demote: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "demote priority"),
+	),
+	Attach: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "attach"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "export output"),
+	),
 }
 
 type groupListKeyMap struct {
@@ -33,3 +53,46 @@ var groupListKeys = groupListKeyMap{
 		key.WithHelp("enter", "view group"),
 	),
 }
+
+type problemListKeyMap struct {
+	Enter key.Binding
+}
+
+var problemListKeys = problemListKeyMap{
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open in editor"),
+	),
+}
+
+type groupKeyMap struct {
+	CancelRemaining key.Binding
+	RetryFailed     key.Binding
+	ExecutionPlan   key.Binding
+}
+
+var groupKeys = groupKeyMap{
+	CancelRemaining: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "cancel remaining"),
+	),
+	RetryFailed: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "retry failed"),
+	),
+	ExecutionPlan: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "view execution plan"),
+	),
+}
+
+type executionPlanKeyMap struct {
+	Run key.Binding
+}
+
+var executionPlanKeys = executionPlanKeyMap{
+	Run: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "run held tasks"),
+	),
+}
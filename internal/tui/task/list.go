@@ -26,6 +26,16 @@ var (
 		Title: "STATUS",
 		Width: task.MaxStatusLen,
 	}
+	priorityColumn = table.Column{
+		Key:   "priority",
+		Title: "PRIORITY",
+		Width: 8,
+	}
+	attemptColumn = table.Column{
+		Key:   "attempt",
+		Title: "ATTEMPT",
+		Width: 7,
+	}
 	ageColumn = table.Column{
 		Key:   "age",
 		Title: "AGE",
@@ -67,6 +77,8 @@ func (mm *ListMaker) Make(_ resource.ID, width, height int) (tui.ChildModel, err
 		table.WorkspaceColumn,
 		commandColumn,
 		statusColumn,
+		priorityColumn,
+		attemptColumn,
 		table.SummaryColumn,
 		ageColumn,
 	}
@@ -77,6 +89,8 @@ func (mm *ListMaker) Make(_ resource.ID, width, height int) (tui.ChildModel, err
 			commandColumn.Key:         t.String(),
 			ageColumn.Key:             tui.Ago(time.Now(), t.Updated),
 			statusColumn.Key:          mm.Helpers.TaskStatus(t, true),
+			priorityColumn.Key:        mm.Helpers.TaskPriority(t),
+			attemptColumn.Key:         mm.Helpers.TaskAttempt(t),
 			table.SummaryColumn.Key:   mm.Helpers.TaskSummary(t, true),
 		}
 	}
@@ -130,20 +144,18 @@ func (m *List) Update(msg tea.Msg) tea.Cmd {
 			if err != nil {
 				return tui.ReportError(fmt.Errorf("applying tasks: %w", err))
 			}
-			return tui.YesNoPrompt(
-				fmt.Sprintf("Apply %d plans?", len(ids)),
-				m.CreateTasks(m.plans.ApplyPlan, ids...),
-			)
+			return m.ApplyPlans(fmt.Sprintf("Apply %d plans?", len(ids)), ids...)
+		case key.Matches(msg, localKeys.Bump):
+			return reprioritize(m.tasks.Bump, m.SelectedOrCurrentIDs()...)
+		case key.Matches(msg, localKeys.Demote):
+			return reprioritize(m.tasks.Demote, m.SelectedOrCurrentIDs()...)
 		case key.Matches(msg, keys.Common.Retry):
 			rows := m.SelectedOrCurrent()
-			specs := make([]task.Spec, len(rows))
+			tasks := make([]*task.Task, len(rows))
 			for i, row := range rows {
-				specs[i] = row.Value.Spec
+				tasks[i] = row.Value
 			}
-			return tui.YesNoPrompt(
-				fmt.Sprintf("Retry %d tasks?", len(rows)),
-				m.CreateTasksWithSpecs(specs...),
-			)
+			return m.RetryTasks(fmt.Sprintf("Retry %d tasks?", len(rows)), tasks...)
 		default:
 			cmd := m.common.Update(msg)
 			cmds = append(cmds, cmd)
@@ -167,6 +179,8 @@ func (m List) HelpBindings() []key.Binding {
 		keys.Common.Cancel,
 		keys.Common.State,
 		keys.Common.Retry,
+		localKeys.Bump,
+		localKeys.Demote,
 	}
 	if _, err := m.allPlans(); err == nil {
 		bindings = append(bindings, localKeys.ApplyPlan)
@@ -137,19 +137,16 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 		case key.Matches(msg, keys.Common.Cancel):
 			return cancel(m.tasks, m.task.ID)
 		case key.Matches(msg, keys.Common.AutoApply):
-			spec, err := m.plans.ApplyPlan(m.task.ID)
-			if err != nil {
-				return tui.ReportError(err)
-			}
-			return tui.YesNoPrompt(
-				"Apply plan?",
-				m.CreateTasksWithSpecs(spec),
-			)
+			return m.ApplyPlans("Apply plan?", m.task.ID)
 		case key.Matches(msg, keys.Common.Retry):
-			return tui.YesNoPrompt(
-				"Retry task?",
-				m.CreateTasksWithSpecs(m.task.Spec),
-			)
+			return m.RetryTasks("Retry task?", m.task)
+		case key.Matches(msg, localKeys.Attach):
+			if !m.task.Attachable() {
+				return tui.ReportError(errors.New("task is not running in a pseudo-terminal"))
+			}
+			return tui.CmdHandler(tui.AttachMsg{Task: m.task})
+		case key.Matches(msg, localKeys.Export):
+			return export(m.tasks, fmt.Sprintf("task-%d", m.task.ID.Serial), m.task.ID)
 		default:
 			cmd := m.common.Update(msg)
 			cmds = append(cmds, cmd)
@@ -249,6 +246,36 @@ func (m *Model) View() string {
 			"",
 			fmt.Sprintf("Dependencies: %v", m.task.DependsOn),
 		)
+		if m.task.RetryOf != nil || m.task.RetriedBy != nil || m.task.Retrying {
+			attempt := fmt.Sprintf("Attempt: %s", m.TaskAttempt(m.task))
+			if m.task.RetryOf != nil {
+				attempt += fmt.Sprintf("\nRetry of: %s", m.task.RetryOf)
+			}
+			if m.task.RetriedBy != nil {
+				attempt += fmt.Sprintf("\nRetried by: %s", m.task.RetriedBy)
+			}
+			content = lipgloss.JoinVertical(lipgloss.Top, content, "", attempt)
+		}
+		if results := m.policyResults(); len(results) > 0 {
+			policies := []string{tui.Bold.Render("Policies")}
+			for _, result := range results {
+				policies = append(policies, renderPolicyResult(result))
+				for _, addr := range result.Addresses {
+					policies = append(policies, "  "+addr)
+				}
+			}
+			content = lipgloss.JoinVertical(lipgloss.Top, content, "", lipgloss.JoinVertical(lipgloss.Top, policies...))
+		}
+		if m.task.PostHookOutput != "" || m.task.PostHookErr != nil {
+			postHooks := []string{tui.Bold.Render("Post-hooks")}
+			if out := strings.TrimSpace(m.task.PostHookOutput); out != "" {
+				postHooks = append(postHooks, out)
+			}
+			if m.task.PostHookErr != nil {
+				postHooks = append(postHooks, tui.Regular.Foreground(tui.Red).Render("✗ "+m.task.PostHookErr.Error()))
+			}
+			content = lipgloss.JoinVertical(lipgloss.Top, content, "", lipgloss.JoinVertical(lipgloss.Top, postHooks...))
+		}
 
 		// Word wrap task info to ensure it wraps "cleanly".
 		// Wrap on spaces and path separator
@@ -270,6 +297,26 @@ func (m *Model) View() string {
 	return lipgloss.JoinHorizontal(lipgloss.Left, components...)
 }
 
+// policyResults returns the results of evaluating policies against the plan
+// created by the task, if the task is a plan task.
+func (m Model) policyResults() []plan.PolicyResult {
+	if m.task.Identifier != plan.PlanTask {
+		return nil
+	}
+	results, err := m.plans.PolicyResults(m.task.ID)
+	if err != nil {
+		return nil
+	}
+	return results
+}
+
+func renderPolicyResult(result plan.PolicyResult) string {
+	if result.Passed {
+		return tui.Regular.Foreground(tui.Green).Render("✓ " + result.String())
+	}
+	return tui.Regular.Foreground(tui.Red).Render("✗ " + result.String())
+}
+
 func boolToOnOff(b bool) string {
 	if b {
 		return "on"
@@ -292,6 +339,14 @@ func (m Model) BorderText() map[tui.BorderPosition]string {
 		bottomLeft += " "
 		bottomLeft += summary
 	}
+	if violations := plan.Violations(m.policyResults()); len(violations) > 0 {
+		bottomLeft += " "
+		bottomLeft += tui.Regular.Foreground(tui.Red).Render("✗ policies violated: " + strings.Join(violations, ", "))
+	}
+	if m.task.PostHookErr != nil {
+		bottomLeft += " "
+		bottomLeft += tui.Regular.Foreground(tui.Red).Render("✗ post-hook failed")
+	}
 	return map[tui.BorderPosition]string{
 		tui.TopLeftBorder:    topRight,
 		tui.BottomLeftBorder: bottomLeft,
@@ -304,10 +359,18 @@ func (m Model) HelpBindings() []key.Binding {
 		keys.Common.State,
 		keys.Common.Retry,
 		localKeys.ToggleInfo,
+		localKeys.Export,
 	}
 	if err := plan.IsApplyable(m.task); err == nil {
 		bindings = append(bindings, localKeys.ApplyPlan)
 	}
+	if m.task.PTY && m.task.State == task.Running {
+		bindings = append(bindings, localKeys.Attach)
+	}
+	if m.viewport.Searching() {
+		bindings = append(bindings, keys.Search.NextMatch, keys.Search.PrevMatch)
+	}
+	bindings = append(bindings, keys.Search.FirstError)
 	bindings = append(bindings, m.common.HelpBindings()...)
 	return bindings
 }
@@ -0,0 +1,37 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+)
+
+// reprioritize task(s), either bumping or demoting their priority using fn.
+func reprioritize(fn func(resource.ID) (*task.Task, error), taskIDs ...resource.ID) tea.Cmd {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		if len(taskIDs) == 1 {
+			t, err := fn(taskIDs[0])
+			if err != nil {
+				return tui.ErrorMsg(fmt.Errorf("reprioritizing task: %w", err))
+			}
+			return tui.InfoMsg(fmt.Sprintf("set task priority to %s", t.Priority))
+		}
+		var errored bool
+		for _, id := range taskIDs {
+			if _, err := fn(id); err != nil {
+				errored = true
+			}
+		}
+		if errored {
+			return tui.ErrorMsg(errors.New("one or more tasks could not be reprioritized; see logs"))
+		}
+		return tui.InfoMsg(fmt.Sprintf("reprioritized %d tasks", len(taskIDs)))
+	}
+}
@@ -0,0 +1,151 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/table"
+)
+
+var (
+	severityColumn = table.Column{
+		Key:   "severity",
+		Title: "SEVERITY",
+		Width: len("SEVERITY"),
+	}
+	fileColumn = table.Column{
+		Key:            "file",
+		Title:          "FILE",
+		TruncationFunc: table.TruncateLeft,
+		FlexFactor:     1,
+	}
+	problemColumn = table.Column{
+		Key:        "problem",
+		Title:      "PROBLEM",
+		FlexFactor: 2,
+	}
+)
+
+// ProblemListMaker makes models listing the diagnostics reported by
+// terraform across all modules.
+type ProblemListMaker struct {
+	Tasks   *task.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *ProblemListMaker) Make(_ resource.ID, width, height int) (tui.ChildModel, error) {
+	columns := []table.Column{
+		table.ModuleColumn,
+		table.WorkspaceColumn,
+		severityColumn,
+		fileColumn,
+		problemColumn,
+	}
+	renderer := func(d task.Diagnostic) table.RenderedRow {
+		row := table.RenderedRow{
+			severityColumn.Key: severity(d.Severity),
+			problemColumn.Key:  d.Summary,
+		}
+		if d.ModuleID != nil {
+			if mod, err := mm.Helpers.Modules.Get(*d.ModuleID); err == nil {
+				row[table.ModuleColumn.Key] = tui.ModulePath(mod.Path)
+			}
+		}
+		if d.WorkspaceID != nil {
+			if ws, err := mm.Helpers.Workspaces.Get(*d.WorkspaceID); err == nil {
+				row[table.WorkspaceColumn.Key] = tui.WorkspaceName(ws.Name)
+			}
+		}
+		if d.Filename != "" {
+			row[fileColumn.Key] = fmt.Sprintf("%s:%d", d.Filename, d.Line)
+		}
+		return row
+	}
+	tbl := table.New(
+		columns,
+		renderer,
+		width,
+		height,
+		table.WithSortFunc(task.SortDiagnostics),
+		table.WithSelectable[task.Diagnostic](false),
+	)
+	return &problemList{
+		Model:   tbl,
+		tasks:   mm.Tasks,
+		Helpers: mm.Helpers,
+	}, nil
+}
+
+type problemList struct {
+	table.Model[task.Diagnostic]
+	*tui.Helpers
+
+	tasks *task.Service
+}
+
+func (m *problemList) Init() tea.Cmd {
+	return func() tea.Msg {
+		return table.BulkInsertMsg[task.Diagnostic](m.tasks.Diagnostics())
+	}
+}
+
+func (m *problemList) Update(msg tea.Msg) tea.Cmd {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, problemListKeys.Enter):
+			if row, ok := m.CurrentRow(); ok {
+				if row.Value.Path == "" {
+					return tui.ReportError(errors.New("problem does not refer to a file"))
+				}
+				return tui.OpenEditor(row.Value.Path, row.Value.Line)
+			}
+		}
+	case resource.Event[*task.Task]:
+		// A finished task that reports diagnostics replaces the diagnostics
+		// previously reported for its module and workspace.
+		if msg.Payload.Spec.Diagnostics && msg.Payload.State.IsFinal() {
+			m.SetItems(m.tasks.Diagnostics()...)
+		}
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	var cmd tea.Cmd
+	m.Model, cmd = m.Model.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+func (m problemList) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder:   tui.Bold.Render("problems"),
+		tui.TopMiddleBorder: m.Metadata(),
+	}
+}
+
+func (m problemList) View() string {
+	return m.Model.View()
+}
+
+func (m problemList) HelpBindings() []key.Binding {
+	return []key.Binding{problemListKeys.Enter}
+}
+
+func severity(s task.Severity) string {
+	switch s {
+	case task.SeverityError:
+		return tui.Regular.Foreground(tui.Red).Render(string(s))
+	case task.SeverityWarning:
+		return tui.Regular.Foreground(tui.Yellow).Render(string(s))
+	default:
+		return string(s)
+	}
+}
@@ -0,0 +1,46 @@
+package top
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// escapeSequences maps keys to the escape sequences a terminal sends for them.
+var escapeSequences = map[tea.KeyType]string{
+	tea.KeyUp:       "\x1b[A",
+	tea.KeyDown:     "\x1b[B",
+	tea.KeyRight:    "\x1b[C",
+	tea.KeyLeft:     "\x1b[D",
+	tea.KeyHome:     "\x1b[H",
+	tea.KeyEnd:      "\x1b[F",
+	tea.KeyShiftTab: "\x1b[Z",
+	tea.KeyInsert:   "\x1b[2~",
+	tea.KeyDelete:   "\x1b[3~",
+	tea.KeyPgUp:     "\x1b[5~",
+	tea.KeyPgDown:   "\x1b[6~",
+}
+
+// keyToBytes converts a key back into the input a terminal would send to a
+// program for the key.
+func keyToBytes(msg tea.KeyMsg) []byte {
+	var b []byte
+	if msg.Alt {
+		b = append(b, '\x1b')
+	}
+	switch {
+	case msg.Type == tea.KeyRunes:
+		b = append(b, string(msg.Runes)...)
+	case msg.Type == tea.KeySpace:
+		b = append(b, ' ')
+	case msg.Type >= 0:
+		// Control keys, including enter, tab, backspace and escape, are
+		// their control codes.
+		b = append(b, byte(msg.Type))
+	default:
+		seq, ok := escapeSequences[msg.Type]
+		if !ok {
+			return nil
+		}
+		b = append(b, seq...)
+	}
+	return b
+}
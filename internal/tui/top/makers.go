@@ -65,6 +65,17 @@ func makeMakers(
 			LogModelMaker: logMaker,
 		},
 		tui.LogKind: logMaker,
+		tui.FormatDiffKind: &explorer.FormatDiffMaker{
+			Helpers: helpers,
+		},
+		tui.ExecutionPlanKind: &tasktui.ExecutionPlanMaker{
+			Tasks:   app.Tasks,
+			Helpers: helpers,
+		},
+		tui.ProblemListKind: &tasktui.ProblemListMaker{
+			Tasks:   app.Tasks,
+			Helpers: helpers,
+		},
 		tui.ResourceListKind: &workspacetui.ResourceListMaker{
 			States:     app.States,
 			Workspaces: app.Workspaces,
@@ -29,21 +29,25 @@ const (
 	normalMode mode = iota // default
 	promptMode             // confirm prompt is visible and taking input
 	filterMode             // filter is visible and taking input
+	attachMode             // keys are forwarded to an attached task
 )
 
 type model struct {
 	*tui.PaneManager
 
-	makers     map[tui.Kind]tui.Maker
-	modules    *module.Service
-	width      int
-	height     int
-	mode       mode
+	makers  map[tui.Kind]tui.Maker
+	modules *module.Service
+	width   int
+	height  int
+	mode    mode
+	// attached is the task to which keys are forwarded in attach mode.
+	attached   *task.Task
 	showHelp   bool
 	prompt     *tui.Prompt
 	dump       *os.File
 	workdir    string
 	tasks      *task.Service
+	helpers    *tui.Helpers
 	spinner    *spinner.Model
 	spinning   bool
 	lastTaskID *resource.ID
@@ -71,6 +75,7 @@ func newModel(cfg app.Config, app *app.App) (model, error) {
 		Plans:      app.Plans,
 		States:     app.States,
 		Tasks:      app.Tasks,
+		Workflows:  app.Workflows,
 		Logger:     app.Logger,
 		Workdir:    cfg.Workdir,
 	}
@@ -83,6 +88,7 @@ func newModel(cfg app.Config, app *app.App) (model, error) {
 		modules:     app.Modules,
 		spinner:     &spinner,
 		tasks:       app.Tasks,
+		helpers:     helpers,
 		dump:        dump,
 		workdir:     cfg.Workdir.PrettyString(),
 	}
@@ -119,6 +125,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// No tasks are running so stop spinner
 			m.spinning = false
 		}
+		// Detach from the attached task once it has finished.
+		if m.mode == attachMode && msg.Payload == m.attached && msg.Payload.State.IsFinal() {
+			m.mode = normalMode
+			m.attached = nil
+		}
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		*m.spinner, cmd = m.spinner.Update(msg)
@@ -187,12 +198,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			Width:  m.viewWidth(),
 		})
 		return m, tea.Batch(cmd, blink)
+	case tui.AttachMsg:
+		m.mode = attachMode
+		m.attached = msg.Task
+		return m, nil
 	case tea.KeyMsg:
 		// Pressing any key makes any info/error message in the footer disappear
 		m.info = ""
 		m.err = nil
 
 		switch m.mode {
+		case attachMode:
+			if key.Matches(msg, keys.Attach.Detach) {
+				m.mode = normalMode
+				m.attached = nil
+				return m, nil
+			}
+			if err := m.attached.WriteInput(keyToBytes(msg)); err != nil {
+				m.mode = normalMode
+				m.attached = nil
+				return m, tui.ReportError(fmt.Errorf("detached from task: %w", err))
+			}
+			return m, nil
 		case promptMode:
 			closePrompt, cmd := m.prompt.HandleKey(msg)
 			if closePrompt {
@@ -242,6 +269,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Global.Suspend):
 			// ctrl-z suspends the app
 			return m, tea.Suspend
+		case key.Matches(msg, keys.Global.PauseQueue):
+			// ctrl-p toggles pausing the task queue
+			if m.tasks.Paused() {
+				m.tasks.Resume()
+				return m, tui.ReportInfo("Resumed task queue")
+			}
+			m.tasks.Pause()
+			return m, tui.ReportInfo("Paused task queue: running tasks are left to finish")
+		case key.Matches(msg, keys.Global.DryRun):
+			// ctrl-y toggles dry-run mode
+			m.helpers.DryRun = !m.helpers.DryRun
+			if m.helpers.DryRun {
+				return m, tui.ReportInfo("Enabled dry-run mode: tasks are held for review rather than run")
+			}
+			return m, tui.ReportInfo("Disabled dry-run mode")
 		case key.Matches(msg, keys.Global.Help):
 			// '?' toggles help widget
 			m.showHelp = !m.showHelp
@@ -264,6 +306,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tui.NavigateTo(tui.TaskGroupListKind)
 		case key.Matches(msg, keys.Global.Logs):
 			return m, tui.NavigateTo(tui.LogListKind)
+		case key.Matches(msg, keys.Global.Problems):
+			return m, tui.NavigateTo(tui.ProblemListKind)
 		case key.Matches(msg, keys.Global.Tasks):
 			return m, tui.NavigateTo(tui.TaskListKind)
 		case key.Matches(msg, keys.Common.LastTask):
@@ -334,6 +378,15 @@ func (m model) View() string {
 	}
 	// Compose footer
 	footer := helpWidget
+	if m.tasks.Paused() {
+		footer += pausedWidget
+	}
+	if m.helpers.DryRun {
+		footer += dryRunWidget
+	}
+	if m.mode == attachMode {
+		footer += attachedWidget
+	}
 	if m.err != nil {
 		footer += tui.Regular.Padding(0, 1).
 			Background(tui.Red).
@@ -367,11 +420,25 @@ func (m model) View() string {
 var (
 	helpWidget    = tui.Padded.Background(tui.Grey).Foreground(tui.White).Render("? help")
 	versionWidget = tui.Padded.Background(tui.DarkGrey).Foreground(tui.White).Render(version.Version)
+	pausedWidget  = tui.Padded.Background(tui.Orange).Foreground(tui.Black).Bold(true).Render("queue paused")
+	dryRunWidget  = tui.Padded.Background(tui.Purple).Foreground(tui.White).Bold(true).Render("dry-run")
+	// attachedWidget is shown in attach mode, reminding the user how to detach.
+	attachedWidget = tui.Padded.Background(tui.Purple).Foreground(tui.White).Bold(true).Render("attached (ctrl+] to detach)")
 )
 
 func (m model) availableFooterMsgWidth() int {
 	// -2 to accommodate padding
-	return max(0, m.width-lipgloss.Width(helpWidget)-lipgloss.Width(versionWidget))
+	width := m.width - lipgloss.Width(helpWidget) - lipgloss.Width(versionWidget)
+	if m.tasks.Paused() {
+		width -= lipgloss.Width(pausedWidget)
+	}
+	if m.helpers.DryRun {
+		width -= lipgloss.Width(dryRunWidget)
+	}
+	if m.mode == attachMode {
+		width -= lipgloss.Width(attachedWidget)
+	}
+	return max(0, width)
 }
 
 // type taskCompletionMsg struct {
@@ -412,6 +479,8 @@ func (m model) help() string {
 		bindings = append(bindings, m.prompt.HelpBindings()...)
 	case filterMode:
 		bindings = append(bindings, keys.KeyMapToSlice(keys.Filter)...)
+	case attachMode:
+		bindings = append(bindings, keys.KeyMapToSlice(keys.Attach)...)
 	default:
 		bindings = append(bindings, m.PaneManager.HelpBindings()...)
 	}
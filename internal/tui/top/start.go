@@ -173,6 +173,12 @@ func setupSubscriptions(app *app.App, cfg app.Config) (chan tea.Msg, func()) {
 		sub := app.Tasks.TaskBroker.Subscribe(ctx)
 		go app.Workspaces.LoadWorkspacesUponInit(sub)
 	}
+	// Record locks on workspace state whenever tasks fail to acquire the
+	// lock.
+	{
+		sub := app.Tasks.TaskBroker.Subscribe(ctx)
+		go app.Workspaces.TrackLocksUponTaskEvent(sub)
+	}
 	// Whenever a workspace is loaded, pull its state
 	{
 		sub := app.Workspaces.Subscribe(ctx)
@@ -2,9 +2,11 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -20,6 +22,18 @@ type Viewport struct {
 	content []byte
 	json    bool
 	spinner *spinner.Model
+	// height is the height of the viewport including the search widget.
+	height int
+
+	// lines are the lines of content, wrapped to the width of the viewport.
+	lines []string
+	// search is the widget for entering a regular expression with which to
+	// search content.
+	search    textinput.Model
+	searchErr error
+	matches   []match
+	// current is the index of the current match.
+	current int
 }
 
 type ViewportOptions struct {
@@ -32,10 +46,14 @@ type ViewportOptions struct {
 }
 
 func NewViewport(opts ViewportOptions) Viewport {
+	search := textinput.New()
+	search.Prompt = "Search: "
+
 	m := Viewport{
 		viewport: viewport.New(0, 0),
 		json:     opts.JSON,
 		spinner:  opts.Spinner,
+		search:   search,
 	}
 	m.SetDimensions(opts.Width, opts.Height)
 	return m
@@ -58,6 +76,55 @@ func (m Viewport) Update(msg tea.Msg) (Viewport, tea.Cmd) {
 			m.viewport.SetYOffset(0)
 		case key.Matches(msg, keys.Navigation.GotoBottom):
 			m.viewport.SetYOffset(m.viewport.TotalLineCount())
+		case key.Matches(msg, keys.Search.NextMatch):
+			if m.Searching() {
+				m.gotoMatch(m.current + 1)
+				return m, nil
+			}
+		case key.Matches(msg, keys.Search.PrevMatch):
+			if m.Searching() {
+				m.gotoMatch(m.current - 1)
+				return m, nil
+			}
+		case key.Matches(msg, keys.Search.FirstError):
+			line, ok := firstError(m.lines)
+			if !ok {
+				return m, ReportInfo("No errors found")
+			}
+			m.viewport.SetYOffset(line)
+			return m, nil
+		}
+	case FilterFocusReqMsg:
+		// Focus the search widget
+		blink := m.search.Focus()
+		m.setHeight()
+		// Start blinking the cursor.
+		return m, blink
+	case FilterBlurMsg:
+		// Blur the search widget, leaving matches highlighted.
+		m.search.Blur()
+		return m, nil
+	case FilterCloseMsg:
+		// Close the search widget, and clear matches.
+		m.search.Blur()
+		m.search.SetValue("")
+		m.setHeight()
+		m.findMatches(false)
+		return m, nil
+	case FilterKeyMsg:
+		// unwrap key and send to search widget
+		var cmd tea.Cmd
+		m.search, cmd = m.search.Update(tea.KeyMsg(msg))
+		// Search incrementally, jumping to the first match from the current
+		// position.
+		m.findMatches(true)
+		return m, cmd
+	default:
+		// Send any other messages to the search widget if it is focused.
+		if m.search.Focused() {
+			var cmd tea.Cmd
+			m.search, cmd = m.search.Update(msg)
+			cmds = append(cmds, cmd)
 		}
 	}
 
@@ -89,7 +156,37 @@ func (m Viewport) View() string {
 		m.viewport.VisibleLineCount(),
 		m.viewport.YOffset,
 	)
-	return lipgloss.JoinHorizontal(lipgloss.Top, output, scrollbar)
+	output = lipgloss.JoinHorizontal(lipgloss.Top, output, scrollbar)
+	if m.Searching() {
+		output = lipgloss.JoinVertical(lipgloss.Left, output, m.searchView())
+	}
+	return output
+}
+
+// searchView renders the search widget along with the number of matches.
+func (m Viewport) searchView() string {
+	var count string
+	switch {
+	case m.searchErr != nil:
+		count = "invalid regex"
+	case len(m.matches) == 0:
+		count = "no matches"
+	default:
+		count = fmt.Sprintf("%d/%d", m.current+1, len(m.matches))
+	}
+	count = Regular.Foreground(LightGrey).Render(count)
+	width := m.viewport.Width + ScrollbarWidth
+	input := Regular.
+		Width(max(0, width-lipgloss.Width(count)-1)).
+		MaxWidth(max(0, width-lipgloss.Width(count)-1)).
+		Render(m.search.View())
+	return lipgloss.JoinHorizontal(lipgloss.Top, input, " ", count)
+}
+
+// Searching returns true if the search widget is visible, which it is if
+// it's either in focus, or it has a non-empty value.
+func (m Viewport) Searching() bool {
+	return m.search.Focused() || m.search.Value() != ""
 }
 
 func (m *Viewport) SetDimensions(width, height int) {
@@ -97,12 +194,23 @@ func (m *Viewport) SetDimensions(width, height int) {
 	// If width has changed, re-wrap existing content.
 	rewrap := m.viewport.Width != width
 	m.viewport.Width = width
-	m.viewport.Height = height
+	m.height = height
+	m.setHeight()
 	if rewrap {
 		m.setContent()
 	}
 }
 
+// setHeight sets the height of the viewport, making room for the search
+// widget if it is visible.
+func (m *Viewport) setHeight() {
+	if m.Searching() {
+		m.viewport.Height = max(0, m.height-1)
+	} else {
+		m.viewport.Height = m.height
+	}
+}
+
 func (m *Viewport) AppendContent(content []byte, finished, autoScroll bool) (err error) {
 	m.content = append(m.content, content...)
 	if finished {
@@ -122,16 +230,79 @@ func (m *Viewport) AppendContent(content []byte, finished, autoScroll bool) (err
 		}
 	}
 	m.setContent()
-	if autoScroll {
+	// Don't scroll away from search matches.
+	if autoScroll && !m.Searching() {
 		m.viewport.GotoBottom()
 	}
 	return err
 }
 
+// SetContent replaces the content of the viewport.
+func (m *Viewport) SetContent(content []byte) {
+	m.content = content
+	m.setContent()
+}
+
 func (m *Viewport) setContent() {
 	// Wrap content to the width of the viewport, whilst respecting ANSI escape
 	// codes (i.e. don't split codes across lines).
 	wrapped := ansi.Wrap(ansi.Wordwrap(string(m.content), m.viewport.Width, ""), m.viewport.Width, "")
 	sanitized := SanitizeColors([]byte(wrapped))
-	m.viewport.SetContent(string(sanitized))
+	m.lines = strings.Split(string(sanitized), "\n")
+	m.findMatches(false)
+}
+
+// findMatches searches the content for the pattern entered into the search
+// widget, and renders the content with any matches highlighted. If jump is
+// true then the viewport jumps to the first match from the current position.
+func (m *Viewport) findMatches(jump bool) {
+	m.matches = nil
+	m.searchErr = nil
+	if pattern := m.search.Value(); pattern != "" {
+		re, err := compileSearch(pattern)
+		if err != nil {
+			m.searchErr = err
+		} else {
+			m.matches = findMatches(re, m.lines)
+		}
+	}
+	if jump {
+		// Jump to first match at or below the top of the viewport.
+		current := 0
+		for i, match := range m.matches {
+			if match.line >= m.viewport.YOffset {
+				current = i
+				break
+			}
+		}
+		m.gotoMatch(current)
+		return
+	}
+	m.current = min(m.current, max(0, len(m.matches)-1))
+	m.render()
+}
+
+// gotoMatch makes the match with the given index the current match,
+// wrapping around at either end, and scrolls it into view.
+func (m *Viewport) gotoMatch(i int) {
+	if len(m.matches) == 0 {
+		m.render()
+		return
+	}
+	m.current = (i + len(m.matches)) % len(m.matches)
+	m.render()
+	line := m.matches[m.current].line
+	if line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height {
+		// Centre match in viewport
+		m.viewport.SetYOffset(line - m.viewport.Height/2)
+	}
+}
+
+// render sets the content of the viewport, highlighting any matches.
+func (m *Viewport) render() {
+	lines := m.lines
+	if len(m.matches) > 0 {
+		lines = highlightMatches(m.lines, m.matches, m.current)
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
 }
@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/workflow"
+)
+
+// pickWorkflow prompts the user to pick a workflow to run on the selected
+// modules or workspaces. If held is true then the workflow's tasks are held
+// for review rather than run.
+func (m *ActionHandler) pickWorkflow(held bool) tea.Cmd {
+	workflows := m.Workflows.List()
+	if len(workflows) == 0 {
+		return ReportError(errors.New("no workflows defined in config file"))
+	}
+	names := make([]string, len(workflows))
+	for i, w := range workflows {
+		names[i] = w.Name
+	}
+	return CmdHandler(PromptMsg{
+		Prompt:      "Run workflow: ",
+		Placeholder: strings.Join(names, ", "),
+		Suggestions: names,
+		Action: func(name string) tea.Cmd {
+			if name == "" {
+				return nil
+			}
+			return m.runWorkflow(name, held)
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
+
+// runWorkflow runs the named workflow on the selected modules or workspaces,
+// taking the user to the workflow's task group, or if held is true, to the
+// group's execution plan.
+func (m *ActionHandler) runWorkflow(name string, held bool) tea.Cmd {
+	w, err := m.Workflows.Get(name)
+	if err != nil {
+		return ReportError(err)
+	}
+	moduleIDs, err := m.GetModuleIDs()
+	if err != nil {
+		return ReportError(err)
+	}
+	var workspaceIDs []resource.ID
+	if w.OnWorkspaces() {
+		workspaceIDs, err = m.GetWorkspaceIDs()
+		if err != nil {
+			return ReportError(err)
+		}
+	}
+	run := func() tea.Msg {
+		group, err := m.Workflows.Run(name, moduleIDs, workspaceIDs, workflow.RunOptions{Held: held})
+		if err != nil {
+			return ErrorMsg(fmt.Errorf("running workflow: %w", err))
+		}
+		if held {
+			return NewNavigationMsg(ExecutionPlanKind, WithParent(group.ID))
+		}
+		return NewNavigationMsg(TaskGroupKind, WithParent(group.ID))
+	}
+	if w.Applies() && !held {
+		return m.ConfirmChanges(
+			fmt.Sprintf("Workflow %s applies changes to %d workspaces. Run workflow?", name, len(workspaceIDs)),
+			workspaceIDs,
+			run,
+		)
+	}
+	return run
+}
@@ -92,8 +92,9 @@ func (m *resourceModel) Update(msg tea.Msg) tea.Cmd {
 			fn := func(workspaceID resource.ID) (task.Spec, error) {
 				return m.states.Delete(workspaceID, m.resource.Address)
 			}
-			return tui.YesNoPrompt(
+			return m.ConfirmChanges(
 				"Delete resource?",
+				[]resource.ID{m.resource.WorkspaceID},
 				m.CreateTasks(fn, m.resource.WorkspaceID),
 			)
 		case key.Matches(msg, keys.Common.PlanDestroy):
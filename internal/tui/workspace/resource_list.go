@@ -154,8 +154,9 @@ func (m *resourceList) Update(msg tea.Msg) tea.Cmd {
 			fn := func(workspaceID resource.ID) (task.Spec, error) {
 				return m.states.Delete(workspaceID, addrs...)
 			}
-			return tui.YesNoPrompt(
+			return m.ConfirmChanges(
 				fmt.Sprintf("Delete %d resource(s)?", len(addrs)),
+				[]resource.ID{m.workspace.ID},
 				m.CreateTasks(fn, m.workspace.ID),
 			)
 		case key.Matches(msg, resourcesKeys.Taint):
@@ -190,12 +191,11 @@ func (m *resourceList) Update(msg tea.Msg) tea.Cmd {
 			// Create a targeted apply.
 			createRunOptions.TargetAddrs = m.selectedOrCurrentAddresses()
 			resourceIDs := m.SelectedOrCurrentIDs()
-			fn := func(workspaceID resource.ID) (task.Spec, error) {
-				return m.plans.Apply(workspaceID, createRunOptions)
-			}
-			return tui.YesNoPrompt(
+			return m.ConfirmAutoApply(
 				fmt.Sprintf(applyPrompt, len(resourceIDs)),
-				m.CreateTasks(fn, m.workspace.ID),
+				[]resource.ID{m.workspace.ID},
+				createRunOptions,
+				m.CreateTasks,
 			)
 		}
 	case initState:
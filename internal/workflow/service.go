@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+)
+
+type Service struct {
+	workflows []Workflow
+	modules   *module.Service
+	plans     *plan.Service
+	tasks     *task.Service
+	logger    logging.Interface
+}
+
+type ServiceOptions struct {
+	Workflows []Workflow
+	Modules   *module.Service
+	Plans     *plan.Service
+	Tasks     *task.Service
+	Logger    logging.Interface
+}
+
+func NewService(opts ServiceOptions) *Service {
+	return &Service{
+		workflows: opts.Workflows,
+		modules:   opts.Modules,
+		plans:     opts.Plans,
+		tasks:     opts.Tasks,
+		logger:    opts.Logger,
+	}
+}
+
+// List lists workflows in the order in which they're defined.
+func (s *Service) List() []Workflow {
+	return s.workflows
+}
+
+// Get retrieves a workflow by name.
+func (s *Service) Get(name string) (Workflow, error) {
+	for _, w := range s.workflows {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+	return Workflow{}, fmt.Errorf("workflow not found: %s", name)
+}
+
+// RunOptions are options for running a workflow.
+type RunOptions struct {
+	// Held holds the workflow's tasks for review rather than running them.
+	Held bool
+}
+
+// Run runs a workflow, creating a task group. Steps carried out on modules
+// create a task for each of the given modules, and steps carried out on
+// workspaces create a task for each of the given workspaces.
+func (s *Service) Run(name string, moduleIDs, workspaceIDs []resource.ID, opts RunOptions) (*task.Group, error) {
+	w, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	// Selecting several workspaces of the same module yields the module more
+	// than once, but module steps are to be carried out only once per module.
+	moduleIDs = uniq(moduleIDs)
+
+	var (
+		steps   = make([][]task.Spec, len(w.Steps))
+		applies = w.planApplies()
+		// pending maps the index of a step applying plans to the specs
+		// applying the plans, keyed by workspace ID.
+		pending = make(map[int]map[resource.ID]task.Spec)
+	)
+	for i, step := range w.Steps {
+		ids := moduleIDs
+		if step.workspace() {
+			ids = workspaceIDs
+		}
+		for _, id := range ids {
+			var (
+				spec task.Spec
+				err  error
+			)
+			if j, ok := applies[i]; ok {
+				// A later step applies the plan, so create the spec applying
+				// the plan now, for the later step to use.
+				var apply task.Spec
+				spec, apply, err = s.plans.PlanAndApply(id, plan.CreateOptions{Destroy: step.Action == PlanDestroyAction})
+				if pending[j] == nil {
+					pending[j] = make(map[resource.ID]task.Spec)
+				}
+				pending[j][id] = apply
+			} else if apply, ok := pending[i][id]; ok {
+				spec = apply
+			} else {
+				spec, err = s.createSpec(step, id)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("workflow %s: step %d: %s: %w", w.Name, i+1, step, err)
+			}
+			spec.Held = opts.Held
+			steps[i] = append(steps[i], spec)
+		}
+	}
+	group, err := s.tasks.CreateWorkflowGroup(w.Name, steps...)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("running workflow", "workflow", w.Name, "group", group)
+	return group, nil
+}
+
+// createSpec creates a task spec for a step, carried out on either a module or
+// a workspace.
+func (s *Service) createSpec(step Step, id resource.ID) (task.Spec, error) {
+	if step.Exec != "" {
+		// split value into program and any args
+		parts, err := internal.SplitWords(step.Exec)
+		if err != nil {
+			return task.Spec{}, err
+		}
+		if len(parts) == 0 {
+			return task.Spec{}, errors.New("exec cannot be empty")
+		}
+		return s.modules.Execute(id, parts[0], parts[1:]...)
+	}
+	switch step.Action {
+	case InitAction:
+		return s.modules.Init(id, false)
+	case InitUpgradeAction:
+		return s.modules.Init(id, true)
+	case ValidateAction:
+		return s.modules.Validate(id)
+	case FormatAction:
+		return s.modules.Format(id)
+	case PlanAction:
+		return s.plans.Plan(id, plan.CreateOptions{})
+	case PlanDestroyAction:
+		return s.plans.Plan(id, plan.CreateOptions{Destroy: true})
+	case ApplyAction:
+		return s.plans.Apply(id, plan.CreateOptions{})
+	case DestroyAction:
+		return s.plans.Apply(id, plan.CreateOptions{Destroy: true})
+	default:
+		return task.Spec{}, fmt.Errorf("unknown action: %q", step.Action)
+	}
+}
+
+// uniq removes duplicate IDs, retaining the order of the IDs.
+func uniq(ids []resource.ID) []resource.ID {
+	seen := make(map[resource.ID]struct{}, len(ids))
+	unique := make([]resource.ID, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
+}
@@ -0,0 +1,170 @@
+// package workflow provides user-defined workflows: ordered steps of pug
+// actions or arbitrary programs, run together as a task group.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/leg100/pug/internal"
+	"gopkg.in/yaml.v3"
+)
+
+// Action is a built-in pug action that can be used as a workflow step.
+type Action string
+
+const (
+	InitAction        Action = "init"
+	InitUpgradeAction Action = "init-upgrade"
+	ValidateAction    Action = "validate"
+	FormatAction      Action = "fmt"
+	PlanAction        Action = "plan"
+	PlanDestroyAction Action = "plan-destroy"
+	ApplyAction       Action = "apply"
+	DestroyAction     Action = "destroy"
+)
+
+var (
+	// moduleActions are actions carried out on modules.
+	moduleActions = []Action{InitAction, InitUpgradeAction, ValidateAction, FormatAction}
+	// workspaceActions are actions carried out on workspaces.
+	workspaceActions = []Action{PlanAction, PlanDestroyAction, ApplyAction, DestroyAction}
+)
+
+// Workflow is a named sequence of steps. The tasks of each step only run once
+// the tasks of the previous step for the same module have succeeded.
+type Workflow struct {
+	Name  string `yaml:"name"`
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is either a built-in action, or an arbitrary program executed in the
+// module directory.
+type Step struct {
+	Action Action
+	// Exec is a program and its args, split into words in the manner of a
+	// shell.
+	Exec string
+}
+
+// UnmarshalYAML unmarshals a step, which is either the name of an action, e.g.
+// `plan`, or a mapping specifying a program to execute, e.g. `exec: tflint`.
+func (s *Step) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Action = Action(node.Value)
+		return nil
+	}
+	var step struct {
+		Exec string `yaml:"exec"`
+	}
+	if err := node.Decode(&step); err != nil {
+		return err
+	}
+	s.Exec = step.Exec
+	return nil
+}
+
+func (s Step) String() string {
+	if s.Exec != "" {
+		return s.Exec
+	}
+	return string(s.Action)
+}
+
+// workspace returns true if the step is carried out on workspaces rather than
+// on modules.
+func (s Step) workspace() bool {
+	return slices.Contains(workspaceActions, s.Action)
+}
+
+// applies returns true if the step applies changes to infrastructure.
+func (s Step) applies() bool {
+	return s.Action == ApplyAction || s.Action == DestroyAction
+}
+
+// Validate validates the workflow.
+func (w Workflow) Validate() error {
+	if w.Name == "" {
+		return errors.New("workflow name cannot be empty")
+	}
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("workflow %s: no steps specified", w.Name)
+	}
+	for i, step := range w.Steps {
+		if step.Exec != "" {
+			words, err := internal.SplitWords(step.Exec)
+			if err != nil {
+				return fmt.Errorf("workflow %s: step %d: exec: %w", w.Name, i+1, err)
+			}
+			if len(words) == 0 {
+				return fmt.Errorf("workflow %s: step %d: exec cannot be empty", w.Name, i+1)
+			}
+			continue
+		}
+		if !slices.Contains(moduleActions, step.Action) && !step.workspace() {
+			return fmt.Errorf("workflow %s: step %d: unknown action: %q", w.Name, i+1, step.Action)
+		}
+	}
+	applies := w.planApplies()
+	for i, step := range w.Steps {
+		j, ok := applies[i]
+		if !ok {
+			continue
+		}
+		if (step.Action == PlanDestroyAction) != (w.Steps[j].Action == DestroyAction) {
+			return fmt.Errorf("workflow %s: step %d: %s cannot apply the plan of step %d: %s", w.Name, j+1, w.Steps[j], i+1, step)
+		}
+	}
+	return nil
+}
+
+// planApplies maps the index of each plan step to the index of the step that
+// applies its plan, if any. An apply step applies the plan of the latest
+// preceding plan step, and a destroy step likewise applies the plan of a
+// plan-destroy step, unless the plan has already been applied. An apply or
+// destroy step without a preceding plan step auto-applies instead.
+func (w Workflow) planApplies() map[int]int {
+	applies := make(map[int]int)
+	planned := -1
+	for i, step := range w.Steps {
+		switch step.Action {
+		case PlanAction, PlanDestroyAction:
+			planned = i
+		case ApplyAction, DestroyAction:
+			if planned >= 0 {
+				applies[planned] = i
+			}
+			planned = -1
+		}
+	}
+	return applies
+}
+
+// Applies returns true if any of the workflow's steps applies changes to
+// infrastructure.
+func (w Workflow) Applies() bool {
+	return slices.ContainsFunc(w.Steps, Step.applies)
+}
+
+// OnWorkspaces returns true if any of the workflow's steps are carried out on
+// workspaces.
+func (w Workflow) OnWorkspaces() bool {
+	return slices.ContainsFunc(w.Steps, Step.workspace)
+}
+
+// ValidateAll validates the workflows, additionally checking that their names
+// are unique.
+func ValidateAll(workflows []Workflow) error {
+	names := make(map[string]struct{}, len(workflows))
+	for _, w := range workflows {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[w.Name]; ok {
+			return fmt.Errorf("duplicate workflow name: %s", w.Name)
+		}
+		names[w.Name] = struct{}{}
+	}
+	return nil
+}
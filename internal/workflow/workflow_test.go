@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWorkflow_UnmarshalYAML(t *testing.T) {
+	input := `
+name: check
+steps:
+- init
+- validate
+- exec: tflint --format compact
+- plan
+`
+	var got Workflow
+	require.NoError(t, yaml.Unmarshal([]byte(input), &got))
+
+	want := Workflow{
+		Name: "check",
+		Steps: []Step{
+			{Action: InitAction},
+			{Action: ValidateAction},
+			{Exec: "tflint --format compact"},
+			{Action: PlanAction},
+		},
+	}
+	assert.Equal(t, want, got)
+	assert.NoError(t, got.Validate())
+	assert.False(t, got.Applies())
+}
+
+func TestValidateAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		workflows []Workflow
+		wantErr   string
+	}{
+		{
+			name: "valid",
+			workflows: []Workflow{
+				{Name: "check", Steps: []Step{{Action: InitAction}, {Action: PlanAction}}},
+				{Name: "deploy", Steps: []Step{{Action: ApplyAction}}},
+			},
+		},
+		{
+			name:      "missing name",
+			workflows: []Workflow{{Steps: []Step{{Action: InitAction}}}},
+			wantErr:   "workflow name cannot be empty",
+		},
+		{
+			name:      "no steps",
+			workflows: []Workflow{{Name: "check"}},
+			wantErr:   "workflow check: no steps specified",
+		},
+		{
+			name:      "unknown action",
+			workflows: []Workflow{{Name: "check", Steps: []Step{{Action: "deploy"}}}},
+			wantErr:   `workflow check: step 1: unknown action: "deploy"`,
+		},
+		{
+			name:      "unterminated quote in exec",
+			workflows: []Workflow{{Name: "check", Steps: []Step{{Exec: "tflint --var 'a=b"}}}},
+			wantErr:   "workflow check: step 1: exec: unterminated single quote",
+		},
+		{
+			name: "apply plan",
+			workflows: []Workflow{
+				{Name: "deploy", Steps: []Step{{Action: PlanAction}, {Exec: "tflint"}, {Action: ApplyAction}}},
+				{Name: "teardown", Steps: []Step{{Action: PlanDestroyAction}, {Action: DestroyAction}}},
+			},
+		},
+		{
+			name:      "apply destroy plan",
+			workflows: []Workflow{{Name: "deploy", Steps: []Step{{Action: PlanDestroyAction}, {Action: ApplyAction}}}},
+			wantErr:   "workflow deploy: step 2: apply cannot apply the plan of step 1: plan-destroy",
+		},
+		{
+			name:      "destroy with plan",
+			workflows: []Workflow{{Name: "teardown", Steps: []Step{{Action: PlanAction}, {Action: DestroyAction}}}},
+			wantErr:   "workflow teardown: step 2: destroy cannot apply the plan of step 1: plan",
+		},
+		{
+			name: "duplicate names",
+			workflows: []Workflow{
+				{Name: "check", Steps: []Step{{Action: InitAction}}},
+				{Name: "check", Steps: []Step{{Action: PlanAction}}},
+			},
+			wantErr: "duplicate workflow name: check",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAll(tt.workflows)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWorkflow_PlanApplies(t *testing.T) {
+	w := Workflow{Steps: []Step{
+		{Action: InitAction},
+		{Action: PlanAction},
+		{Exec: "tflint"},
+		{Action: ApplyAction},
+		{Action: ApplyAction},
+		{Action: PlanDestroyAction},
+		{Action: PlanDestroyAction},
+		{Action: DestroyAction},
+	}}
+	// The second apply auto-applies because the plan has already been
+	// applied, and the first plan-destroy is superseded by the second.
+	assert.Equal(t, map[int]int{1: 3, 6: 7}, w.planApplies())
+}
@@ -15,6 +15,9 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// CostTask identifies a task retrieving a breakdown of costs.
+const CostTask task.Identifier = "cost"
+
 type costTaskSpecCreator struct {
 	*Service
 }
@@ -54,6 +57,7 @@ func (s *costTaskSpecCreator) Cost(workspaceIDs ...resource.ID) (task.Spec, erro
 		}
 	}
 	return task.Spec{
+		Identifier: CostTask,
 		Execution: task.Execution{
 			Program: "infracost",
 			Args: []string{
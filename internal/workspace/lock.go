@@ -0,0 +1,108 @@
+package workspace
+
+import (
+	"errors"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+)
+
+// ForceUnlockTask is the identifier of a task that forcibly unlocks a
+// workspace's state.
+const ForceUnlockTask task.Identifier = "force-unlock"
+
+// TrackLocksUponTaskEvent records a lock on a workspace's state whenever a task
+// fails because the state is locked. The lock is cleared once the state is
+// forcibly unlocked, or once a task of the same type as the failed task
+// succeeds.
+func (s *Service) TrackLocksUponTaskEvent(sub <-chan resource.Event[*task.Task]) {
+	// Each failed task is only recorded once, to avoid re-recording a lock
+	// that has since been cleared whenever the failed task is updated.
+	recorded := make(map[resource.ID]struct{})
+	for event := range sub {
+		t := event.Payload
+		if t.WorkspaceID == nil {
+			continue
+		}
+		switch t.State {
+		case task.Errored:
+			var lockErr *task.LockError
+			if !errors.As(t.Err, &lockErr) {
+				continue
+			}
+			if _, ok := recorded[t.ID]; ok {
+				continue
+			}
+			recorded[t.ID] = struct{}{}
+			s.table.Update(*t.WorkspaceID, func(existing *Workspace) error {
+				existing.Lock = &Lock{
+					LockInfo:   lockErr.LockInfo,
+					TaskID:     t.ID,
+					identifier: t.Identifier,
+				}
+				return nil
+			})
+		case task.Exited:
+			ws, err := s.table.Get(*t.WorkspaceID)
+			if err != nil || ws.Lock == nil {
+				continue
+			}
+			if t.Identifier != ForceUnlockTask && t.Identifier != ws.Lock.identifier {
+				continue
+			}
+			s.table.Update(ws.ID, func(existing *Workspace) error {
+				existing.Lock = nil
+				return nil
+			})
+		}
+	}
+}
+
+// ForceUnlock forcibly unlocks the workspace's state, using the ID of the
+// lock that caused a task to fail. Once unlocked, the failed task is retried,
+// unless the workspace is protected: the failed task may well change the
+// workspace, e.g. an apply, and such changes must be confirmed by the user, so
+// the user must instead retry it themselves.
+func (s *Service) ForceUnlock(workspaceID resource.ID) (task.Spec, error) {
+	ws, err := s.table.Get(workspaceID)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	if ws.Lock == nil {
+		return task.Spec{}, errors.New("workspace state is not known to be locked")
+	}
+	mod, err := s.modules.Get(ws.ModuleID)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	lock := ws.Lock
+	return task.Spec{
+		ModuleID:    &mod.ID,
+		WorkspaceID: &ws.ID,
+		Path:        mod.Path,
+		Env:         []string{ws.TerraformEnv()},
+		Identifier:  ForceUnlockTask,
+		Execution: task.Execution{
+			TerraformCommand: []string{"force-unlock"},
+			// Skip terraform's confirmation prompt; the user is prompted
+			// by pug instead.
+			Args: []string{"-force", lock.ID},
+		},
+		Blocking: true,
+		Short:    true,
+		AfterExited: func(*task.Task) {
+			if ws.Protected {
+				s.logger.Info("not retrying task after unlocking state of protected workspace", "workspace", ws)
+				return
+			}
+			failed, err := s.tasks.Get(lock.TaskID)
+			if err != nil {
+				s.logger.Error("retrying task after unlocking state", "error", err, "workspace", ws)
+				return
+			}
+			if _, err := s.tasks.Create(failed.Spec); err != nil {
+				s.logger.Error("retrying task after unlocking state", "error", err, "task", failed)
+			}
+		},
+	}, nil
+}
@@ -0,0 +1,75 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/pubsub"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_TrackLocksUponTaskEvent(t *testing.T) {
+	mod := &module.Module{ID: resource.NewID(resource.Module), Path: "a/b/c"}
+	ws, err := New(mod, "dev")
+	require.NoError(t, err)
+
+	lockErr := &task.LockError{LockInfo: task.LockInfo{ID: "lock-123", Who: "louis@laptop"}}
+	failed := &task.Task{ID: resource.NewID(resource.Task), WorkspaceID: &ws.ID, Identifier: "plan", State: task.Errored, Err: lockErr}
+	unrelated := &task.Task{ID: resource.NewID(resource.Task), WorkspaceID: &ws.ID, Identifier: "state-reload", State: task.Exited}
+	retried := &task.Task{ID: resource.NewID(resource.Task), WorkspaceID: &ws.ID, Identifier: "plan", State: task.Exited}
+	unlocked := &task.Task{ID: resource.NewID(resource.Task), WorkspaceID: &ws.ID, Identifier: ForceUnlockTask, State: task.Exited}
+
+	tests := []struct {
+		name   string
+		events []*task.Task
+		want   *Lock
+	}{
+		{
+			name:   "locked",
+			events: []*task.Task{failed},
+			want:   &Lock{LockInfo: lockErr.LockInfo, TaskID: failed.ID, identifier: "plan"},
+		},
+		{
+			name:   "remain locked after different type of task succeeds",
+			events: []*task.Task{failed, unrelated},
+			want:   &Lock{LockInfo: lockErr.LockInfo, TaskID: failed.ID, identifier: "plan"},
+		},
+		{
+			name:   "unlocked after same type of task succeeds",
+			events: []*task.Task{failed, retried},
+			want:   nil,
+		},
+		{
+			name:   "unlocked after force-unlock",
+			events: []*task.Task{failed, unlocked},
+			want:   nil,
+		},
+		{
+			name:   "remain unlocked after subsequent event for failed task",
+			events: []*task.Task{failed, unlocked, failed},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := resource.NewTable(pubsub.NewBroker[*Workspace](logging.Discard))
+			table.Add(ws.ID, &Workspace{ID: ws.ID, Name: ws.Name})
+			svc := &Service{table: table}
+
+			events := make(chan resource.Event[*task.Task], len(tt.events))
+			for _, ev := range tt.events {
+				events <- resource.Event[*task.Task]{Type: resource.UpdatedEvent, Payload: ev}
+			}
+			close(events)
+			svc.TrackLocksUponTaskEvent(events)
+
+			got, err := table.Get(ws.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Lock)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/leg100/pug/internal"
+)
+
+// Protection protects workspaces from accidental changes.
+type Protection struct {
+	// Patterns match the workspaces to protect, each of the form
+	// <module>[:<workspace>], where <module> is a glob matching the module
+	// path, and <workspace> is a glob matching the workspace name. If
+	// <workspace> is omitted then all the module's workspaces are protected.
+	Patterns []string
+	// ForbidAutoApply forbids auto-applying protected workspaces, permitting
+	// only the apply of a plan.
+	ForbidAutoApply bool
+}
+
+// ParseProtection parses patterns matching the workspaces to protect, e.g.
+// prod/** or **:prod.
+func ParseProtection(patterns []string, forbidAutoApply bool) (Protection, error) {
+	for _, pattern := range patterns {
+		if err := ValidatePattern(pattern); err != nil {
+			return Protection{}, fmt.Errorf("invalid protect pattern: %s: %w", pattern, err)
+		}
+	}
+	return Protection{Patterns: patterns, ForbidAutoApply: forbidAutoApply}, nil
+}
+
+// protect marks the workspace as protected if it matches a pattern.
+func (p Protection) protect(ws *Workspace) {
+	for _, pattern := range p.Patterns {
+		if !ws.Matches(pattern) {
+			continue
+		}
+		ws.Protected = true
+		ws.ForbidAutoApply = p.ForbidAutoApply
+		return
+	}
+}
+
+// ValidatePattern validates a pattern matching workspaces, of the form
+// <module>[:<workspace>], where <module> is a glob matching the module path,
+// and <workspace> is a glob matching the workspace name.
+func ValidatePattern(pattern string) error {
+	modulePattern, workspacePattern, _ := strings.Cut(pattern, ":")
+	if modulePattern == "" {
+		return errors.New("missing module")
+	}
+	for _, glob := range append(strings.Split(modulePattern, "/"), workspacePattern) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the workspace matches the pattern. See
+// ValidatePattern for the form of the pattern. If the pattern omits the
+// workspace then all of the module's workspaces match.
+func (ws *Workspace) Matches(pattern string) bool {
+	modulePattern, workspacePattern, found := strings.Cut(pattern, ":")
+	if !internal.MatchGlob(modulePattern, ws.ModulePath) {
+		return false
+	}
+	if found {
+		if ok, _ := path.Match(workspacePattern, ws.Name); !ok {
+			return false
+		}
+	}
+	return true
+}
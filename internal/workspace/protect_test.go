@@ -0,0 +1,46 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/module"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtection(t *testing.T) {
+	tests := []struct {
+		name       string
+		patterns   []string
+		modulePath string
+		workspace  string
+		want       bool
+	}{
+		{"module glob", []string{"prod/**"}, "prod/network", "default", true},
+		{"module glob mismatch", []string{"prod/**"}, "dev/network", "default", false},
+		{"workspace glob", []string{"**:prod"}, "modules/network", "prod", true},
+		{"workspace glob mismatch", []string{"**:prod"}, "modules/network", "dev", false},
+		{"module and workspace glob", []string{"modules/*:prod-*"}, "modules/network", "prod-eu", true},
+		{"no patterns", nil, "modules/network", "prod", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseProtection(tt.patterns, true)
+			require.NoError(t, err)
+
+			ws, err := New(module.New(module.Options{Path: tt.modulePath}), tt.workspace)
+			require.NoError(t, err)
+			p.protect(ws)
+
+			assert.Equal(t, tt.want, ws.Protected)
+			assert.Equal(t, tt.want, ws.ForbidAutoApply)
+		})
+	}
+}
+
+func TestParseProtection_Invalid(t *testing.T) {
+	for _, pattern := range []string{":prod", "prod/[", "prod:["} {
+		_, err := ParseProtection([]string{pattern}, false)
+		assert.Error(t, err, pattern)
+	}
+}
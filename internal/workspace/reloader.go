@@ -13,6 +13,9 @@ import (
 	"github.com/leg100/pug/internal/task"
 )
 
+// ReloadTask identifies a task reloading a module's workspaces.
+const ReloadTask task.Identifier = "workspace list"
+
 type reloader struct {
 	*Service
 }
@@ -53,8 +56,9 @@ func (r *reloader) Reload(moduleID resource.ID) (task.Spec, error) {
 		return task.Spec{}, err
 	}
 	return task.Spec{
-		ModuleID: &mod.ID,
-		Path:     mod.Path,
+		ModuleID:   &mod.ID,
+		Path:       mod.Path,
+		Identifier: ReloadTask,
 		Execution: task.Execution{
 			TerraformCommand: []string{"workspace", "list"},
 		},
@@ -93,6 +97,7 @@ func (r *reloader) resetWorkspaces(mod *module.Module, discovered []string, curr
 			if err != nil {
 				return nil, nil, fmt.Errorf("adding workspace: %w", err)
 			}
+			r.protection.protect(add)
 			r.table.Add(add.ID, add)
 			added = append(added, name)
 		}
@@ -19,6 +19,8 @@ type Service struct {
 	tasks   *task.Service
 	datadir string
 	workdir internal.Workdir
+	// protection determines which workspaces are protected.
+	protection Protection
 
 	*pubsub.Broker[*Workspace]
 	*reloader
@@ -26,11 +28,12 @@ type Service struct {
 }
 
 type ServiceOptions struct {
-	Tasks   *task.Service
-	Modules *module.Service
-	Logger  logging.Interface
-	DataDir string
-	Workdir internal.Workdir
+	Tasks      *task.Service
+	Modules    *module.Service
+	Logger     logging.Interface
+	DataDir    string
+	Workdir    internal.Workdir
+	Protection Protection
 }
 
 type workspaceTable interface {
@@ -60,13 +63,14 @@ func NewService(opts ServiceOptions) *Service {
 	})
 
 	s := &Service{
-		Broker:  broker,
-		table:   table,
-		modules: opts.Modules,
-		tasks:   opts.Tasks,
-		logger:  opts.Logger,
-		datadir: opts.DataDir,
-		workdir: opts.Workdir,
+		Broker:     broker,
+		table:      table,
+		modules:    opts.Modules,
+		tasks:      opts.Tasks,
+		logger:     opts.Logger,
+		datadir:    opts.DataDir,
+		workdir:    opts.Workdir,
+		protection: opts.Protection,
 	}
 	s.reloader = &reloader{s}
 	s.costTaskSpecCreator = &costTaskSpecCreator{s}
@@ -114,6 +118,34 @@ func (s *Service) LoadWorkspacesUponInit(sub <-chan resource.Event[*task.Task])
 	}
 }
 
+// LoadWorkspacesUponChange reloads workspaces for a module whenever a change is
+// detected on disk that may affect its workspaces. If only the current
+// workspace may have changed and it is already known to pug then it is set as
+// the current workspace without running a task; otherwise a task is created to
+// reload the module's workspaces.
+func (s *Service) LoadWorkspacesUponChange(changes <-chan module.WorkspaceChange) {
+	for change := range changes {
+		if change.CurrentOnly {
+			mod, err := s.modules.Get(change.ModuleID)
+			if err != nil {
+				continue
+			}
+			current := currentWorkspaceName(s.workdir, mod.Path)
+			if ws, err := s.GetByName(mod.Path, current); err == nil {
+				if mod.CurrentWorkspaceID == nil || *mod.CurrentWorkspaceID != ws.ID {
+					if err := s.modules.SetCurrent(mod.ID, ws.ID); err != nil {
+						s.logger.Error("setting current workspace", "error", err, "module", mod)
+					}
+				}
+				continue
+			}
+		}
+		if err := s.createReloadTask(change.ModuleID); err != nil {
+			s.logger.Error("reloading workspaces", "error", err, "module", change.ModuleID)
+		}
+	}
+}
+
 // Create a workspace. Asynchronous.
 func (s *Service) Create(path, name string) (task.Spec, error) {
 	mod, err := s.modules.GetByPath(path)
@@ -124,6 +156,7 @@ func (s *Service) Create(path, name string) (task.Spec, error) {
 	if err != nil {
 		return task.Spec{}, err
 	}
+	s.protection.protect(ws)
 	return task.Spec{
 		ModuleID: &mod.ID,
 		Path:     mod.Path,
@@ -6,10 +6,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
 )
 
 type Workspace struct {
@@ -19,6 +21,26 @@ type Workspace struct {
 	ModuleID   resource.ID
 	ModulePath string
 	Cost       *float64
+	// Lock is non-nil if a task failed because the workspace's state is
+	// locked.
+	Lock *Lock
+	// Protected is true if the workspace is protected from accidental
+	// changes: the user must type the workspace's module path and name to
+	// confirm them.
+	Protected bool
+	// ForbidAutoApply is true if the workspace is protected and can only be
+	// applied from a plan.
+	ForbidAutoApply bool
+}
+
+// Lock is a lock on a workspace's state that caused a task to fail.
+type Lock struct {
+	task.LockInfo
+
+	// TaskID is the ID of the task that failed to acquire the lock.
+	TaskID resource.ID
+	// identifier identifies the type of task that failed to acquire the lock.
+	identifier task.Identifier
 }
 
 func New(mod *module.Module, name string) (*Workspace, error) {
@@ -59,3 +81,15 @@ func (ws *Workspace) VarsFile(workdir internal.Workdir) (string, bool) {
 func TerraformEnv(workspaceName string) string {
 	return fmt.Sprintf("TF_WORKSPACE=%s", workspaceName)
 }
+
+// currentWorkspaceName returns the name of the current workspace for the module
+// with the given path, as recorded by terraform in the .terraform/environment
+// file. If the file does not exist then the current workspace is the default
+// workspace.
+func currentWorkspaceName(workdir internal.Workdir, modulePath string) string {
+	b, err := os.ReadFile(workdir.Join(modulePath, ".terraform", "environment"))
+	if err != nil {
+		return "default"
+	}
+	return strings.TrimSpace(string(b))
+}